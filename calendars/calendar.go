@@ -0,0 +1,431 @@
+package calendars
+
+import (
+	"errors"
+	"fmt"
+	"github.com/robfig/cron"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	TypeHoliday = Type("HOLIDAY")
+	TypeWeekly  = Type("WEEKLY")
+	TypeMonthly = Type("MONTHLY")
+	TypeDaily   = Type("DAILY")
+	TypeCron    = Type("CRON")
+)
+
+// max excluded ranges skipped by single NextIncluded call, calendar which excludes all of them excludes everything
+const maxSkips = 10000
+
+// max steps made by cron calendar to find end of excluded range, calendar which needs more excludes everything
+const maxCronSteps = 1000
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrEmptyCalendarName = errors.New("empty calendar name")
+	ErrCalendarInUse     = errors.New("calendar is referenced by triggers")
+	ErrNotSupported      = errors.New("store does not support calendars")
+	ErrEmptyTimeRange    = errors.New("empty excluded time range")
+	ErrInvalidTimeOfDay  = "invalid time of day: %v"
+	ErrInvalidCronSpec   = "invalid cron spec: %v"
+	ErrInvalidDayOfMonth = "invalid day of month: %v"
+	// only calendars of this package could be stored
+	ErrUnsupportedCalendar = "unsupported calendar type: %v"
+)
+
+type Type string
+
+// excludes times from fire times of triggers referencing it by name
+type Calendar interface {
+	Type() Type
+	// calendar whose excluded times are excluded by this calendar too, nil if not set
+	Base() Calendar
+	SetBase(base Calendar)
+	// location excluded dates and times of day are taken in
+	Location() *time.Location
+	SetLocation(loc *time.Location)
+	IsIncluded(t time.Time) bool
+	// first included time not before given time, zero if calendar excludes everything after it
+	NextIncluded(t time.Time) time.Time
+}
+
+// calendar with own exclusion rule
+type exclusion interface {
+	Calendar
+	// first time after excluded range of calendar itself containing given time, false if time is not excluded.
+	// zero time if excluded range does not end
+	excluded(t time.Time) (time.Time, bool)
+}
+
+type calendar struct {
+	base Calendar
+	loc  *time.Location
+}
+
+func (c *calendar) Base() Calendar {
+	return c.base
+}
+
+func (c *calendar) SetBase(base Calendar) {
+	c.base = base
+}
+
+func (c *calendar) Location() *time.Location {
+	if c.loc == nil {
+		return time.Local
+	}
+	return c.loc
+}
+
+func (c *calendar) SetLocation(loc *time.Location) {
+	c.loc = loc
+}
+
+func isIncluded(c exclusion, t time.Time) bool {
+	if _, ok := c.excluded(t.In(c.Location())); ok {
+		return false
+	}
+	return c.Base() == nil || c.Base().IsIncluded(t)
+}
+
+func nextIncluded(c exclusion, t time.Time) time.Time {
+	loc := t.Location()
+	t = t.In(c.Location())
+	for i := 0; i < maxSkips; i++ {
+		if until, ok := c.excluded(t); ok {
+			if until.IsZero() {
+				return until
+			}
+			t = until
+			continue
+		}
+		if c.Base() == nil {
+			return t.In(loc)
+		}
+		next := c.Base().NextIncluded(t)
+		if next.IsZero() || next.Equal(t) {
+			return next.In(loc)
+		}
+		t = next.In(c.Location())
+	}
+	return time.Time{}
+}
+
+func nextDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+}
+
+// excludes whole days of holidays
+type HolidayCalendar struct {
+	calendar
+	dates map[string]struct{}
+}
+
+func NewHolidayCalendar(dates ...time.Time) *HolidayCalendar {
+	c := &HolidayCalendar{dates: make(map[string]struct{})}
+	for _, d := range dates {
+		c.AddHoliday(d)
+	}
+	return c
+}
+
+func (c *HolidayCalendar) Type() Type {
+	return TypeHoliday
+}
+
+// exclude day of given date, its time and location are ignored
+func (c *HolidayCalendar) AddHoliday(date time.Time) {
+	c.dates[date.Format(dateLayout)] = struct{}{}
+}
+
+func (c *HolidayCalendar) RemoveHoliday(date time.Time) {
+	delete(c.dates, date.Format(dateLayout))
+}
+
+// holidays sorted ascending at midnight of calendar location
+func (c *HolidayCalendar) Holidays() []time.Time {
+	arr := make([]time.Time, 0, len(c.dates))
+	for s := range c.dates {
+		d, _ := time.ParseInLocation(dateLayout, s, c.Location())
+		arr = append(arr, d)
+	}
+	sort.Slice(arr, func(i, j int) bool { return arr[i].Before(arr[j]) })
+	return arr
+}
+
+func (c *HolidayCalendar) IsIncluded(t time.Time) bool {
+	return isIncluded(c, t)
+}
+
+func (c *HolidayCalendar) NextIncluded(t time.Time) time.Time {
+	return nextIncluded(c, t)
+}
+
+func (c *HolidayCalendar) excluded(t time.Time) (time.Time, bool) {
+	if _, ok := c.dates[t.Format(dateLayout)]; ok {
+		return nextDay(t), true
+	}
+	return time.Time{}, false
+}
+
+// excludes whole days of weekdays
+type WeeklyCalendar struct {
+	calendar
+	days [7]bool
+}
+
+func NewWeeklyCalendar(days ...time.Weekday) *WeeklyCalendar {
+	c := &WeeklyCalendar{}
+	for _, d := range days {
+		c.days[d] = true
+	}
+	return c
+}
+
+func (c *WeeklyCalendar) Type() Type {
+	return TypeWeekly
+}
+
+func (c *WeeklyCalendar) ExcludedDays() []time.Weekday {
+	arr := make([]time.Weekday, 0)
+	for d, ok := range c.days {
+		if ok {
+			arr = append(arr, time.Weekday(d))
+		}
+	}
+	return arr
+}
+
+func (c *WeeklyCalendar) IsIncluded(t time.Time) bool {
+	return isIncluded(c, t)
+}
+
+func (c *WeeklyCalendar) NextIncluded(t time.Time) time.Time {
+	return nextIncluded(c, t)
+}
+
+func (c *WeeklyCalendar) excluded(t time.Time) (time.Time, bool) {
+	if c.days[t.Weekday()] {
+		return nextDay(t), true
+	}
+	return time.Time{}, false
+}
+
+// excludes whole days of month, negative day is counted from end of month, e.g. -1 is last day
+type MonthlyCalendar struct {
+	calendar
+	days []int
+}
+
+func NewMonthlyCalendar(days ...int) (*MonthlyCalendar, error) {
+	for _, d := range days {
+		if d == 0 || d < -31 || d > 31 {
+			return nil, fmt.Errorf(ErrInvalidDayOfMonth, d)
+		}
+	}
+	return &MonthlyCalendar{days: append([]int(nil), days...)}, nil
+}
+
+func (c *MonthlyCalendar) Type() Type {
+	return TypeMonthly
+}
+
+func (c *MonthlyCalendar) ExcludedDays() []int {
+	return append([]int(nil), c.days...)
+}
+
+func (c *MonthlyCalendar) IsIncluded(t time.Time) bool {
+	return isIncluded(c, t)
+}
+
+func (c *MonthlyCalendar) NextIncluded(t time.Time) time.Time {
+	return nextIncluded(c, t)
+}
+
+func (c *MonthlyCalendar) excluded(t time.Time) (time.Time, bool) {
+	y, m, d := t.Date()
+	last := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, day := range c.days {
+		if day == d || last+day+1 == d {
+			return nextDay(t), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// excludes range of time of every day, range wraps midnight if it ends before it starts
+type DailyCalendar struct {
+	calendar
+	from     time.Duration
+	to       time.Duration
+	inverted bool
+}
+
+// exclude times of day within [from, to) given as "15:04" or "15:04:05"
+func NewDailyCalendar(from, to string) (*DailyCalendar, error) {
+	f, err := parseTimeOfDay(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseTimeOfDay(to)
+	if err != nil {
+		return nil, err
+	}
+	if f == t {
+		return nil, ErrEmptyTimeRange
+	}
+	return &DailyCalendar{from: f, to: t}, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	layout := "15:04:05"
+	if strings.Count(s, ":") == 1 {
+		layout = "15:04"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return 0, fmt.Errorf(ErrInvalidTimeOfDay, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+}
+
+func formatTimeOfDay(d time.Duration) string {
+	return time.Date(0, 1, 1, 0, 0, 0, int(d), time.UTC).Format("15:04:05")
+}
+
+func (c *DailyCalendar) Type() Type {
+	return TypeDaily
+}
+
+// exclude times of day outside of range instead of within it
+func (c *DailyCalendar) Invert(inverted bool) *DailyCalendar {
+	c.inverted = inverted
+	return c
+}
+
+func (c *DailyCalendar) Inverted() bool {
+	return c.inverted
+}
+
+// bounds of range as "15:04:05"
+func (c *DailyCalendar) TimeRange() (string, string) {
+	return formatTimeOfDay(c.from), formatTimeOfDay(c.to)
+}
+
+func (c *DailyCalendar) IsIncluded(t time.Time) bool {
+	return isIncluded(c, t)
+}
+
+func (c *DailyCalendar) NextIncluded(t time.Time) time.Time {
+	return nextIncluded(c, t)
+}
+
+func (c *DailyCalendar) excluded(t time.Time) (time.Time, bool) {
+	from, to := c.from, c.to
+	//inverted range excludes its complement
+	if c.inverted {
+		from, to = to, from
+	}
+
+	y, m, d := t.Date()
+	tod := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	at := func(day int, offset time.Duration) time.Time {
+		return time.Date(y, m, d+day, 0, 0, 0, int(offset), t.Location())
+	}
+
+	switch {
+	case from < to && tod >= from && tod < to:
+		return at(0, to), true
+	case from > to && tod >= from:
+		return at(1, to), true
+	case from > to && tod < to:
+		return at(0, to), true
+	}
+	return time.Time{}, false
+}
+
+// excludes seconds matched by cron spec, e.g. "* * 0-6 * * *" excludes night hours
+type CronCalendar struct {
+	calendar
+	spec  string
+	sched cron.Schedule
+}
+
+func NewCronCalendar(spec string) (*CronCalendar, error) {
+	sched, err := cron.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf(ErrInvalidCronSpec, err)
+	}
+	return &CronCalendar{spec: spec, sched: sched}, nil
+}
+
+func (c *CronCalendar) Type() Type {
+	return TypeCron
+}
+
+func (c *CronCalendar) Spec() string {
+	return c.spec
+}
+
+func (c *CronCalendar) IsIncluded(t time.Time) bool {
+	return isIncluded(c, t)
+}
+
+func (c *CronCalendar) NextIncluded(t time.Time) time.Time {
+	return nextIncluded(c, t)
+}
+
+func (c *CronCalendar) excluded(t time.Time) (time.Time, bool) {
+	sec := t.Truncate(time.Second)
+	if !c.matches(sec) {
+		return time.Time{}, false
+	}
+	for i := 0; i < maxCronSteps; i++ {
+		next := c.skip(sec)
+		if !next.After(sec) {
+			next = sec.Add(time.Second)
+		}
+		sec = next
+		if !c.matches(sec) {
+			return sec, true
+		}
+	}
+	return time.Time{}, true
+}
+
+// start of next second, minute, hour, day or month, the coarsest one whose all seconds before are matched too
+func (c *CronCalendar) skip(sec time.Time) time.Time {
+	spec, ok := c.sched.(*cron.SpecSchedule)
+	if !ok || !allBits(spec.Second, 0, 59) {
+		return sec.Add(time.Second)
+	}
+	y, m, d := sec.Date()
+	switch {
+	case !allBits(spec.Minute, 0, 59):
+		return time.Date(y, m, d, sec.Hour(), sec.Minute()+1, 0, 0, sec.Location())
+	case !allBits(spec.Hour, 0, 23):
+		return time.Date(y, m, d, sec.Hour()+1, 0, 0, 0, sec.Location())
+	case !allBits(spec.Dom, 1, 31) || !allBits(spec.Dow, 0, 6):
+		return time.Date(y, m, d+1, 0, 0, 0, 0, sec.Location())
+	}
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, sec.Location())
+}
+
+func (c *CronCalendar) matches(sec time.Time) bool {
+	return c.sched.Next(sec.Add(-time.Nanosecond)).Equal(sec)
+}
+
+func allBits(bits uint64, min, max uint) bool {
+	for i := min; i <= max; i++ {
+		if bits&(1<<i) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package calendars
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestCalendars(t *testing.T) {
+	//2030-01-01 is Tuesday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2030, 1, day, hour, min, 0, 0, time.UTC)
+	}
+	inUTC := func(c Calendar) Calendar {
+		c.SetLocation(time.UTC)
+		return c
+	}
+
+	Convey("Test excluded times", t, func() {
+		Convey("holiday calendar must exclude whole days", func() {
+			c := inUTC(NewHolidayCalendar(at(1, 0, 0), at(2, 0, 0)))
+			So(c.IsIncluded(at(1, 12, 0)), ShouldBeFalse)
+			So(c.IsIncluded(at(3, 0, 0)), ShouldBeTrue)
+			So(c.NextIncluded(at(1, 12, 0)).Equal(at(3, 0, 0)), ShouldBeTrue)
+			So(c.NextIncluded(at(5, 12, 0)).Equal(at(5, 12, 0)), ShouldBeTrue)
+		})
+
+		Convey("weekly calendar must exclude weekdays", func() {
+			c := inUTC(NewWeeklyCalendar(time.Saturday, time.Sunday))
+			So(c.IsIncluded(at(5, 10, 0)), ShouldBeFalse)
+			So(c.NextIncluded(at(5, 10, 0)).Equal(at(7, 0, 0)), ShouldBeTrue)
+
+			all := inUTC(NewWeeklyCalendar(time.Sunday, time.Monday, time.Tuesday, time.Wednesday,
+				time.Thursday, time.Friday, time.Saturday))
+			So(all.NextIncluded(at(1, 0, 0)).IsZero(), ShouldBeTrue)
+		})
+
+		Convey("monthly calendar must exclude days counted from both ends of month", func() {
+			_, err := NewMonthlyCalendar(0)
+			So(err, ShouldNotBeNil)
+
+			c, err := NewMonthlyCalendar(1, -1)
+			So(err, ShouldBeNil)
+			c.SetLocation(time.UTC)
+			So(c.IsIncluded(at(1, 10, 0)), ShouldBeFalse)
+			So(c.IsIncluded(at(31, 10, 0)), ShouldBeFalse)
+			So(c.IsIncluded(at(30, 10, 0)), ShouldBeTrue)
+			So(c.NextIncluded(at(31, 10, 0)).Equal(time.Date(2030, 2, 2, 0, 0, 0, 0, time.UTC)), ShouldBeTrue)
+		})
+
+		Convey("daily calendar must exclude time range wrapping midnight", func() {
+			_, err := NewDailyCalendar("10:00", "10:00")
+			So(err, ShouldEqual, ErrEmptyTimeRange)
+			_, err = NewDailyCalendar("25:00", "10:00")
+			So(err, ShouldNotBeNil)
+
+			c, err := NewDailyCalendar("22:00", "06:30")
+			So(err, ShouldBeNil)
+			c.SetLocation(time.UTC)
+			So(c.IsIncluded(at(1, 23, 0)), ShouldBeFalse)
+			So(c.IsIncluded(at(2, 6, 0)), ShouldBeFalse)
+			So(c.IsIncluded(at(2, 6, 30)), ShouldBeTrue)
+			So(c.NextIncluded(at(1, 23, 0)).Equal(at(2, 6, 30)), ShouldBeTrue)
+			So(c.NextIncluded(at(2, 3, 0)).Equal(at(2, 6, 30)), ShouldBeTrue)
+
+			Convey("inverted range must exclude its complement", func() {
+				c.Invert(true)
+				So(c.IsIncluded(at(1, 23, 0)), ShouldBeTrue)
+				So(c.IsIncluded(at(1, 12, 0)), ShouldBeFalse)
+				So(c.NextIncluded(at(1, 12, 0)).Equal(at(1, 22, 0)), ShouldBeTrue)
+			})
+		})
+
+		Convey("cron calendar must exclude matched seconds", func() {
+			_, err := NewCronCalendar("invalid")
+			So(err, ShouldNotBeNil)
+
+			c, err := NewCronCalendar("* * 0-5 * * *")
+			So(err, ShouldBeNil)
+			c.SetLocation(time.UTC)
+			So(c.IsIncluded(at(1, 3, 0)), ShouldBeFalse)
+			So(c.IsIncluded(at(1, 6, 0)), ShouldBeTrue)
+			So(c.NextIncluded(at(1, 3, 0)).Equal(at(1, 6, 0)), ShouldBeTrue)
+
+			Convey("excluded days must be skipped at once", func() {
+				c, err := NewCronCalendar("* * * * * 1-5")
+				So(err, ShouldBeNil)
+				c.SetLocation(time.UTC)
+				started := time.Now()
+				for i := 0; i < 100; i++ {
+					So(c.NextIncluded(at(1, 3, 0)).Equal(at(5, 0, 0)), ShouldBeTrue)
+				}
+				So(time.Since(started), ShouldBeLessThan, time.Second)
+			})
+
+			Convey("calendar excluding all seconds must have no included time", func() {
+				c, err := NewCronCalendar("* * * * * *")
+				So(err, ShouldBeNil)
+				started := time.Now()
+				So(c.IsIncluded(at(1, 3, 0)), ShouldBeFalse)
+				So(c.NextIncluded(at(1, 3, 0)).IsZero(), ShouldBeTrue)
+				So(time.Since(started), ShouldBeLessThan, time.Second)
+			})
+		})
+
+		Convey("calendar must exclude times of its base", func() {
+			weekends := inUTC(NewWeeklyCalendar(time.Saturday, time.Sunday))
+			c := inUTC(NewHolidayCalendar(at(4, 0, 0)))
+			c.SetBase(weekends)
+			So(c.IsIncluded(at(5, 10, 0)), ShouldBeFalse)
+			So(c.NextIncluded(at(4, 10, 0)).Equal(at(7, 0, 0)), ShouldBeTrue)
+		})
+
+		Convey("calendar must take days in its location", func() {
+			c := NewHolidayCalendar(at(2, 0, 0))
+			c.SetLocation(time.FixedZone("UTC+3", 3*3600))
+			So(c.IsIncluded(at(1, 22, 0)), ShouldBeFalse)
+			next := c.NextIncluded(at(1, 22, 0))
+			So(next.Equal(at(2, 21, 0)), ShouldBeTrue)
+			So(next.Location(), ShouldEqual, time.UTC)
+		})
+	})
+
+	Convey("Calendars must be serialized with their bases", t, func() {
+		daily, err := NewDailyCalendar("22:00", "06:00")
+		So(err, ShouldBeNil)
+		daily.Invert(true)
+		monthly, err := NewMonthlyCalendar(-1)
+		So(err, ShouldBeNil)
+		monthly.SetBase(daily)
+		weekly := NewWeeklyCalendar(time.Sunday)
+		weekly.SetBase(monthly)
+		c := NewHolidayCalendar(at(1, 0, 0))
+		c.SetLocation(time.UTC)
+		c.SetBase(weekly)
+
+		b, err := Marshal(c)
+		So(err, ShouldBeNil)
+		restored, err := Unmarshal(b)
+		So(err, ShouldBeNil)
+		So(restored.Type(), ShouldEqual, TypeHoliday)
+		So(restored.Location(), ShouldEqual, time.UTC)
+		So(restored.(*HolidayCalendar).Holidays()[0].Equal(at(1, 0, 0)), ShouldBeTrue)
+		So(restored.Base().(*WeeklyCalendar).ExcludedDays(), ShouldResemble, []time.Weekday{time.Sunday})
+		So(restored.Base().Base().(*MonthlyCalendar).ExcludedDays(), ShouldResemble, []int{-1})
+		restoredDaily := restored.Base().Base().Base().(*DailyCalendar)
+		So(restoredDaily.Inverted(), ShouldBeTrue)
+		from, to := restoredDaily.TimeRange()
+		So(from, ShouldEqual, "22:00:00")
+		So(to, ShouldEqual, "06:00:00")
+
+		cron, err := NewCronCalendar("* * 0-5 * * *")
+		So(err, ShouldBeNil)
+		b, err = Marshal(cron)
+		So(err, ShouldBeNil)
+		restored, err = Unmarshal(b)
+		So(err, ShouldBeNil)
+		So(restored.(*CronCalendar).Spec(), ShouldEqual, "* * 0-5 * * *")
+	})
+}
@@ -0,0 +1,126 @@
+package calendars
+
+import (
+	"fmt"
+	"github.com/d1slike/go-sched/json"
+	"time"
+)
+
+// serialized form of calendar with its base calendars
+type envelope struct {
+	Type     Type      `json:"type"`
+	Location string    `json:"location"`
+	Base     *envelope `json:"base,omitempty"`
+	Dates    []string  `json:"dates,omitempty"`
+	Weekdays []int     `json:"weekdays,omitempty"`
+	Days     []int     `json:"days,omitempty"`
+	From     string    `json:"from,omitempty"`
+	To       string    `json:"to,omitempty"`
+	Inverted bool      `json:"inverted,omitempty"`
+	Spec     string    `json:"spec,omitempty"`
+}
+
+// serialize calendar of this package for storing
+func Marshal(c Calendar) ([]byte, error) {
+	env, err := toEnvelope(c)
+	if err != nil {
+		return nil, err
+	}
+	return json.Provider.Marshal(env)
+}
+
+func Unmarshal(b []byte) (Calendar, error) {
+	env := new(envelope)
+	if err := json.Provider.Unmarshal(b, env); err != nil {
+		return nil, err
+	}
+	return fromEnvelope(env)
+}
+
+func toEnvelope(c Calendar) (*envelope, error) {
+	env := &envelope{Type: c.Type(), Location: c.Location().String()}
+	switch cal := c.(type) {
+	case *HolidayCalendar:
+		for _, d := range cal.Holidays() {
+			env.Dates = append(env.Dates, d.Format(dateLayout))
+		}
+	case *WeeklyCalendar:
+		for _, d := range cal.ExcludedDays() {
+			env.Weekdays = append(env.Weekdays, int(d))
+		}
+	case *MonthlyCalendar:
+		env.Days = cal.ExcludedDays()
+	case *DailyCalendar:
+		env.From, env.To = cal.TimeRange()
+		env.Inverted = cal.Inverted()
+	case *CronCalendar:
+		env.Spec = cal.Spec()
+	default:
+		return nil, fmt.Errorf(ErrUnsupportedCalendar, c.Type())
+	}
+
+	if c.Base() != nil {
+		base, err := toEnvelope(c.Base())
+		if err != nil {
+			return nil, err
+		}
+		env.Base = base
+	}
+
+	return env, nil
+}
+
+func fromEnvelope(env *envelope) (Calendar, error) {
+	var (
+		c   Calendar
+		err error
+	)
+	switch env.Type {
+	case TypeHoliday:
+		cal := NewHolidayCalendar()
+		for _, s := range env.Dates {
+			d, err := time.Parse(dateLayout, s)
+			if err != nil {
+				return nil, err
+			}
+			cal.AddHoliday(d)
+		}
+		c = cal
+	case TypeWeekly:
+		cal := NewWeeklyCalendar()
+		for _, d := range env.Weekdays {
+			cal.days[d%7] = true
+		}
+		c = cal
+	case TypeMonthly:
+		c, err = NewMonthlyCalendar(env.Days...)
+	case TypeDaily:
+		var cal *DailyCalendar
+		if cal, err = NewDailyCalendar(env.From, env.To); err == nil {
+			c = cal.Invert(env.Inverted)
+		}
+	case TypeCron:
+		c, err = NewCronCalendar(env.Spec)
+	default:
+		return nil, fmt.Errorf(ErrUnsupportedCalendar, env.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(env.Location)
+	if err != nil {
+		return nil, err
+	}
+	c.SetLocation(loc)
+
+	if env.Base != nil {
+		base, err := fromEnvelope(env.Base)
+		if err != nil {
+			return nil, err
+		}
+		c.SetBase(base)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/utils"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type customCalendar struct {
+	*calendars.WeeklyCalendar
+}
+
+// store with required methods only
+type basicStore struct {
+	stores.Store
+}
+
+// store which fails to read calendars while unreadable is set
+type unreadableCalendarStore struct {
+	stores.Store
+	stores.CalendarStore
+
+	unreadable *utils.AtomicBool
+	failed     int32
+}
+
+func (s *unreadableCalendarStore) GetCalendar(sName string, name string) (calendars.Calendar, error) {
+	if s.unreadable.Get() {
+		atomic.AddInt32(&s.failed, 1)
+		return nil, errors.New("calendar is unreadable")
+	}
+	return s.CalendarStore.GetCalendar(sName, name)
+}
+
+func TestScheduler_Calendars(t *testing.T) {
+	//2030-01-01 is Tuesday
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2030, 1, d, 9, 0, 0, 0, time.UTC)
+	}
+	holidays := func(days ...int) calendars.Calendar {
+		c := calendars.NewHolidayCalendar()
+		c.SetLocation(time.UTC)
+		for _, d := range days {
+			c.AddHoliday(day(d))
+		}
+		return c
+	}
+	daily := NewTrigger().WithKey("t1").WithCron("0 0 9 * * *").InLocation("UTC").WithCalendar("holidays")
+	nextTime := func(s Scheduler, tKey string) time.Time {
+		tr, err := s.GetTrigger(tKey)
+		So(err, ShouldBeNil)
+		return tr.NextTriggerTime()
+	}
+
+	Convey("Trigger must skip times excluded by its calendar", t, func() {
+		s := NewScheduler("calendars", WithClock(clock.NewFake(start)))
+		So(s.AddCalendar("", holidays(1), false), ShouldEqual, calendars.ErrEmptyCalendarName)
+		So(s.AddCalendar("custom", customCalendar{calendars.NewWeeklyCalendar()}, false), ShouldNotBeNil)
+		So(s.ScheduleJob(NewJob().WithKey("j1").WithType("type"), daily), ShouldEqual, stores.ErrCalendarNotFound)
+
+		So(s.AddCalendar("holidays", holidays(1, 2), false), ShouldBeNil)
+		So(s.AddCalendar("holidays", holidays(1, 2), false), ShouldEqual, stores.ErrCalendarAlreadyExists)
+		So(s.ScheduleJob(NewJob().WithKey("j1").WithType("type"), daily), ShouldBeNil)
+		So(nextTime(s, "t1").Equal(day(3)), ShouldBeTrue)
+
+		names, err := s.GetCalendarNames()
+		So(err, ShouldBeNil)
+		So(names, ShouldResemble, []string{"holidays"})
+
+		Convey("replaced calendar must reschedule its triggers", func() {
+			So(s.AddCalendar("holidays", holidays(1), true), ShouldBeNil)
+			So(nextTime(s, "t1").Equal(day(2)), ShouldBeTrue)
+		})
+
+		Convey("calendar must not be deleted while referenced", func() {
+			ok, err := s.DeleteCalendar("holidays")
+			So(err, ShouldEqual, calendars.ErrCalendarInUse)
+			So(ok, ShouldBeFalse)
+
+			_, err = s.DeleteTrigger("t1")
+			So(err, ShouldBeNil)
+			ok, err = s.DeleteCalendar("holidays")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+		})
+	})
+
+	Convey("Next time after firing must skip excluded times", t, func() {
+		timers := Timers{TriggerStealTimeout: time.Second}
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := NewScheduler("calendars", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().NextTriggerTime()
+			return nil
+		})
+		So(s.AddCalendar("holidays", holidays(1, 2, 4), false), ShouldBeNil)
+		So(s.ScheduleJob(NewJob().WithKey("j1").WithType("type"), daily), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(day(3).Sub(fake.Now()))
+		So((<-fired).Equal(day(3)), ShouldBeTrue)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+		So(nextTime(s, "t1").Equal(day(5)), ShouldBeTrue)
+	})
+	Convey("Trigger must not be fired while its calendar could not be read", t, func() {
+		timers := Timers{TriggerStealTimeout: time.Second}
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		inMemory := stores.NewInMemoryStore()
+		store := &unreadableCalendarStore{
+			Store:         inMemory,
+			CalendarStore: inMemory.(stores.CalendarStore),
+			unreadable:    utils.NewAtomicBool(false),
+		}
+		s := NewScheduler("calendars", WithStore(store), WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- fake.Now()
+			return nil
+		})
+		So(s.AddCalendar("holidays", holidays(1, 2), false), ShouldBeNil)
+		So(s.ScheduleJob(NewJob().WithKey("j1").WithType("type"), daily), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		store.unreadable.Set(true)
+		fireAt(s, fake, timers, "t1", day(3))
+		So(waitFor(func() bool { return atomic.LoadInt32(&store.failed) > 0 }), ShouldBeTrue)
+
+		//trigger is acquired again and left unfired until calendar is read
+		for i := 0; atomic.LoadInt32(&store.failed) < 3 && i < 100; i++ {
+			fake.Advance(timers.TriggerStealTimeout)
+			time.Sleep(10 * time.Millisecond)
+		}
+		So(atomic.LoadInt32(&store.failed), ShouldBeGreaterThanOrEqualTo, 3)
+		So(fired, ShouldBeEmpty)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.TriggeredTimes(), ShouldEqual, 0)
+		So(tr.NextTriggerTime().Equal(day(3)), ShouldBeTrue)
+
+		store.unreadable.Set(false)
+		var firedAt time.Time
+		for i := 0; firedAt.IsZero() && i < 100; i++ {
+			fake.Advance(timers.TriggerStealTimeout)
+			select {
+			case firedAt = <-fired:
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		So(firedAt.IsZero(), ShouldBeFalse)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+		So(nextTime(s, "t1").Equal(day(4)), ShouldBeTrue)
+	})
+
+	Convey("Calendars must not be supported by store without calendars extension", t, func() {
+		s := NewScheduler("calendars", WithStore(basicStore{stores.NewInMemoryStore()}))
+		So(s.AddCalendar("holidays", holidays(1), false), ShouldEqual, calendars.ErrNotSupported)
+		_, err := s.GetCalendarNames()
+		So(err, ShouldEqual, calendars.ErrNotSupported)
+		So(s.ScheduleJob(NewJob().WithKey("j1").WithType("type"), daily), ShouldEqual, calendars.ErrNotSupported)
+		So(s.ScheduleJob(NewJob().WithKey("j1").WithType("type"), NewTrigger().WithKey("t2").WithCron("0 0 9 * * *")), ShouldBeNil)
+	})
+}
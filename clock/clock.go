@@ -0,0 +1,99 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+type Timer interface {
+	Stop() bool
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type realClock struct {
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{t: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t *realTicker) Stop() {
+	t.t.Stop()
+}
+
+// like context.WithTimeout, but deadline is tracked by given clock
+func WithTimeout(parent context.Context, c Clock, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(parent, timeout)
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	tc := &timeoutCtx{
+		Context:  ctx,
+		deadline: c.Now().Add(timeout),
+		expired:  make(chan struct{}),
+	}
+	timer := c.AfterFunc(timeout, func() {
+		close(tc.expired)
+		cancel()
+	})
+
+	return tc, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+type timeoutCtx struct {
+	context.Context
+	deadline time.Time
+	expired  chan struct{}
+}
+
+func (c *timeoutCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *timeoutCtx) Err() error {
+	select {
+	case <-c.expired:
+		return context.DeadlineExceeded
+	default:
+		return c.Context.Err()
+	}
+}
+
+func New() Clock {
+	return realClock{}
+}
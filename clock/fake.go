@@ -0,0 +1,150 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// clock which moves only when it is advanced manually
+type Fake struct {
+	lock    sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	// notified each time new waiter is registered
+	changed chan struct{}
+}
+
+type waiter struct {
+	clock    *Fake
+	deadline time.Time
+	// ticker period, zero for one-shot waiters
+	period time.Duration
+	ch     chan time.Time
+	f      func()
+}
+
+func (f *Fake) Now() time.Time {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	w := &waiter{clock: f, ch: make(chan time.Time, 1)}
+	f.add(w, d)
+	return w.ch
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	w := &waiter{clock: f, f: fn}
+	f.add(w, d)
+	return w
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &waiter{clock: f, period: d, ch: make(chan time.Time, 1)}
+	f.add(w, d)
+	return &fakeTicker{w}
+}
+
+// move clock forward firing all timers and tickers in order of their deadlines
+func (f *Fake) Advance(d time.Duration) {
+	f.lock.Lock()
+	target := f.now.Add(d)
+	for {
+		sort.SliceStable(f.waiters, func(i, j int) bool {
+			return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+		})
+		if len(f.waiters) == 0 || f.waiters[0].deadline.After(target) {
+			break
+		}
+
+		w := f.waiters[0]
+		if w.deadline.After(f.now) {
+			f.now = w.deadline
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			f.waiters = f.waiters[1:]
+		}
+		w.fire(f.now)
+	}
+	f.now = target
+	f.lock.Unlock()
+}
+
+// block until at least n timers and tickers are waiting for clock to be advanced
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.lock.Lock()
+		count, changed := len(f.waiters), f.changed
+		f.lock.Unlock()
+		if count >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+func (f *Fake) add(w *waiter, d time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	w.deadline = f.now.Add(d)
+	f.waiters = append(f.waiters, w)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *Fake) remove(w *waiter) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for i, v := range f.waiters {
+		if v == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *waiter) fire(now time.Time) {
+	if w.f != nil {
+		go w.f()
+		return
+	}
+	//drop tick if previous one has not been received yet like time.Ticker does
+	select {
+	case w.ch <- now:
+	default:
+	}
+}
+
+func (w *waiter) Stop() bool {
+	return w.clock.remove(w)
+}
+
+type fakeTicker struct {
+	*waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.remove(t.waiter)
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{
+		now:     now,
+		changed: make(chan struct{}),
+	}
+}
@@ -0,0 +1,71 @@
+package clock
+
+import (
+	"context"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	Convey("Fake clock must move only when advanced", t, func() {
+		c := NewFake(start)
+		after := c.After(time.Second)
+		fired := make(chan time.Time, 1)
+		c.AfterFunc(2*time.Second, func() {
+			fired <- c.Now()
+		})
+		ticker := c.NewTicker(time.Second)
+
+		So(c.Now(), ShouldEqual, start)
+		select {
+		case <-after:
+			So("after must not fire", ShouldBeEmpty)
+		default:
+		}
+
+		c.Advance(time.Second)
+		So(<-after, ShouldEqual, start.Add(time.Second))
+		So(<-ticker.C(), ShouldEqual, start.Add(time.Second))
+
+		c.Advance(time.Second)
+		So(<-fired, ShouldEqual, start.Add(2*time.Second))
+		So(<-ticker.C(), ShouldEqual, start.Add(2*time.Second))
+
+		ticker.Stop()
+		c.Advance(time.Second)
+		select {
+		case <-ticker.C():
+			So("stopped ticker must not fire", ShouldBeEmpty)
+		default:
+		}
+		So(c.Now(), ShouldEqual, start.Add(3*time.Second))
+	})
+
+	Convey("Stopped timer must not fire", t, func() {
+		c := NewFake(start)
+		timer := c.AfterFunc(time.Second, func() {
+			panic("stopped timer has fired")
+		})
+		So(timer.Stop(), ShouldBeTrue)
+		So(timer.Stop(), ShouldBeFalse)
+		c.Advance(time.Minute)
+	})
+
+	Convey("Timeout context must expire by fake clock", t, func() {
+		c := NewFake(start)
+		ctx, cancel := WithTimeout(context.Background(), c, time.Minute)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		So(ok, ShouldBeTrue)
+		So(deadline, ShouldEqual, start.Add(time.Minute))
+		So(ctx.Err(), ShouldBeNil)
+
+		c.Advance(time.Minute)
+		<-ctx.Done()
+		So(ctx.Err() == context.DeadlineExceeded, ShouldBeTrue)
+	})
+}
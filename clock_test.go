@@ -0,0 +1,215 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+// poll store state changed by executor in background
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 1000; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
+
+func triggeredTimes(s Scheduler, tKey string, times int) func() bool {
+	return func() bool {
+		tr, err := s.GetTrigger(tKey)
+		return err == nil && tr != nil && tr.TriggeredTimes() == triggers.Repeat(times)
+	}
+}
+
+func triggerAcquired(s Scheduler, tKey string) func() bool {
+	return func() bool {
+		tr, err := s.GetTrigger(tKey)
+		return err == nil && tr != nil && tr.State() == triggers.StateAcquired
+	}
+}
+
+func TestScheduler_FakeClock(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second, JobTimeout: 10 * time.Second}
+
+	Convey("Cron trigger must fire on each minute of fake clock", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := NewScheduler("clock", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().NextTriggerTime()
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		for i := 1; i <= 3; i++ {
+			fireTime := start.Truncate(time.Minute).Add(time.Duration(i) * time.Minute)
+
+			//steal loop and recovery ticker are waiting
+			fake.BlockUntil(2)
+			fake.Advance(timers.TriggerStealTimeout)
+			So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+
+			//trigger future is waiting too
+			fake.BlockUntil(3)
+			fake.Advance(fireTime.Sub(fake.Now()))
+			So((<-fired).Equal(fireTime), ShouldBeTrue)
+			So(waitFor(triggeredTimes(s, "t1", i)), ShouldBeTrue)
+
+			tr, err := s.GetTrigger("t1")
+			So(err, ShouldBeNil)
+			So(tr.NextTriggerTime().Equal(fireTime.Add(time.Minute)), ShouldBeTrue)
+		}
+	})
+
+	Convey("Job must time out by fake clock", t, func() {
+		fake := clock.NewFake(start)
+		started := make(chan struct{}, 1)
+		finished := make(chan error, 1)
+		s := NewScheduler("clock", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			started <- struct{}{}
+			<-ctx.Context().Done()
+			finished <- ctx.Context().Err()
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(time.Minute)
+
+		<-started
+		fake.Advance(timers.JobTimeout)
+		So(<-finished == context.DeadlineExceeded, ShouldBeTrue)
+	})
+	Convey("Firing of job without executor must be retried after recovery interval", t, func() {
+		timers := Timers{TriggerStealTimeout: time.Second, TriggerRecoveryInterval: 10 * time.Second}
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := NewScheduler("clock", WithClock(fake), WithTimers(timers))
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fireTime := start.Truncate(time.Minute).Add(time.Minute)
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(fireTime.Sub(fake.Now()))
+
+		retryTime := fireTime.Add(timers.TriggerRecoveryInterval)
+		So(waitFor(func() bool {
+			tr, err := s.GetTrigger("t1")
+			return err == nil && tr.NextTriggerTime().Equal(retryTime)
+		}), ShouldBeTrue)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.TriggeredTimes(), ShouldEqual, 0)
+
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- fake.Now()
+			return nil
+		})
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(retryTime.Sub(fake.Now()))
+		So((<-fired).Equal(retryTime), ShouldBeTrue)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+	})
+
+	Convey("Trigger acquired again must not be fired twice", t, func() {
+		fake := clock.NewFake(start)
+		store := stores.NewInMemoryStore()
+		fired := make(chan time.Time, 2)
+		release := make(chan struct{})
+		s := NewScheduler("clock", WithClock(fake), WithTimers(timers), WithStore(store))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- fake.Now()
+			<-release
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+
+		//trigger is released behind executor while its future is pending
+		ok, err := store.UpdateTriggerState("clock", "t1", []triggers.TriggerState{triggers.StateAcquired}, triggers.StateScheduled)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+
+		fireTime := start.Truncate(time.Minute).Add(time.Minute)
+		fake.BlockUntil(3)
+		fake.Advance(fireTime.Sub(fake.Now()))
+		So((<-fired).Equal(fireTime), ShouldBeTrue)
+		select {
+		case <-fired:
+			So("trigger has been fired twice", ShouldBeEmpty)
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(release)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+	})
+
+	Convey("Frequent recovery ticks must not starve trigger stealing", t, func() {
+		fired := make(chan struct{}, 1)
+		s := NewScheduler("clock", WithTimers(Timers{
+			TriggerStealTimeout:     200 * time.Millisecond,
+			TriggerRecoveryInterval: 20 * time.Millisecond,
+		}))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- struct{}{}
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").After(100*time.Millisecond),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		select {
+		case <-fired:
+		case <-time.After(2 * time.Second):
+			So("trigger has not been stolen", ShouldBeEmpty)
+		}
+	})
+}
@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/executions"
+	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	clusterSName = "cluster"
+)
+
+var clusterTimers = Timers{
+	TriggerStealTimeout:     50 * time.Millisecond,
+	TriggerRecoveryInterval: 200 * time.Millisecond,
+	TriggerRecoveryTimeout:  time.Second,
+	ClusterCheckinInterval:  100 * time.Millisecond,
+	ClusterInstanceTimeout:  500 * time.Millisecond,
+	ClusterAcquireWindow:    2 * time.Second,
+	ClusterAcquireBatchSize: 2,
+}
+
+// nodes lagging behind fake clock which is moved in steps must not be taken for dead
+var steppedClusterTimers = Timers{
+	TriggerStealTimeout:     50 * time.Millisecond,
+	TriggerRecoveryInterval: 200 * time.Millisecond,
+	TriggerRecoveryTimeout:  30 * time.Second,
+	ClusterCheckinInterval:  100 * time.Millisecond,
+	ClusterInstanceTimeout:  30 * time.Second,
+	ClusterAcquireWindow:    2 * time.Second,
+	ClusterAcquireBatchSize: 2,
+}
+
+type firingRecorder struct {
+	lock   sync.Mutex
+	fired  map[string]int
+	byNode map[string]int
+}
+
+func (r *firingRecorder) executor(node string) JobExecutor {
+	return func(ctx JobContext) error {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		key := fmt.Sprintf("%s@%d", ctx.Trigger().Key(), ctx.Trigger().NextTriggerTime().Unix())
+		r.fired[key]++
+		r.byNode[node]++
+		return nil
+	}
+}
+
+func (r *firingRecorder) firings() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.fired)
+}
+
+// move fake clock in small steps so that nodes compete for each firing
+func advanceUntil(fake *clock.Fake, cond func() bool) {
+	for i := 0; !cond() && i < 1000; i++ {
+		fake.Advance(50 * time.Millisecond)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newClusterNodes(store stores.Store, recorder *firingRecorder, count int, opts ...Option) []Scheduler {
+	nodes := make([]Scheduler, 0, count)
+	for i := 0; i < count; i++ {
+		node := fmt.Sprintf("node%d", i)
+		s := NewScheduler(
+			clusterSName,
+			append([]Option{
+				WithStore(store),
+				WithClustering(),
+				WithInstanceID(node),
+				WithTimers(clusterTimers),
+			}, opts...)...,
+		)
+		s.RegisterExecutor("type", recorder.executor(node))
+		nodes = append(nodes, s)
+	}
+	return nodes
+}
+
+func TestCluster_ExactlyOnce(t *testing.T) {
+	Convey("Each firing must be executed exactly once across cluster", t, func() {
+		store := stores.NewInMemoryStore()
+		fake := clock.NewFake(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+		recorder := &firingRecorder{fired: make(map[string]int), byNode: make(map[string]int)}
+		nodes := newClusterNodes(store, recorder, 3, WithClock(fake), WithTimers(steppedClusterTimers))
+
+		for i := 0; i < 6; i++ {
+			err := nodes[0].ScheduleJob(
+				NewJob().WithKey(fmt.Sprintf("j%d", i)).WithType("type"),
+				NewTrigger().WithKey(fmt.Sprintf("t%d", i)).WithCron("* * * * * *"),
+			)
+			So(err, ShouldBeNil)
+		}
+
+		for _, n := range nodes {
+			n.Start()
+		}
+		advanceUntil(fake, func() bool { return recorder.firings() >= 6*2 })
+		for _, n := range nodes {
+			So(n.Shutdown(context.Background()), ShouldBeNil)
+		}
+
+		recorder.lock.Lock()
+		defer recorder.lock.Unlock()
+
+		So(len(recorder.fired), ShouldBeGreaterThanOrEqualTo, 6*2)
+		for key, count := range recorder.fired {
+			So(fmt.Sprintf("%s:%d", key, count), ShouldEqual, fmt.Sprintf("%s:%d", key, 1))
+		}
+		So(len(recorder.byNode), ShouldBeGreaterThan, 1)
+
+		instances, err := store.GetInstances(clusterSName)
+		So(err, ShouldBeNil)
+		So(instances, ShouldBeEmpty)
+	})
+}
+
+func TestCluster_DeadInstanceTakeover(t *testing.T) {
+	Convey("Triggers of dead instance must be reassigned", t, func() {
+		store := stores.NewInMemoryStore()
+		fake := clock.NewFake(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+		recorder := &firingRecorder{fired: make(map[string]int), byNode: make(map[string]int)}
+
+		tr, err := NewTrigger().WithKey("t1").WithCron("* * * * * *").ToImmutable()
+		So(err, ShouldBeNil)
+		tr = internal.ModifyTrigger(tr, func(tr *internal.Trigger) {
+			tr.TjobKey = "j1"
+			tr.Tstate = triggers.StateScheduled
+			tr.TnextTime = fake.Now().Add(time.Second)
+		})
+		j, err := NewJob().WithKey("j1").WithType("type").ToImmutable()
+		So(err, ShouldBeNil)
+		So(store.InsertJob(clusterSName, j), ShouldBeNil)
+		So(store.InsertTrigger(clusterSName, tr), ShouldBeNil)
+
+		//dead instance has acquired trigger and has not reported since
+		_, err = store.AcquireTriggers(clusterSName, "dead", fake.Now(), time.Time{}, 0)
+		So(err, ShouldBeNil)
+		So(store.Heartbeat(clusterSName, "dead", fake.Now().Add(-time.Minute)), ShouldBeNil)
+
+		nodes := newClusterNodes(store, recorder, 2, WithClock(fake), WithTimers(steppedClusterTimers))
+		for _, n := range nodes {
+			n.Start()
+		}
+		advanceUntil(fake, func() bool { return recorder.firings() >= 2 })
+		for _, n := range nodes {
+			So(n.Shutdown(context.Background()), ShouldBeNil)
+		}
+
+		recorder.lock.Lock()
+		defer recorder.lock.Unlock()
+		So(len(recorder.fired), ShouldBeGreaterThan, 0)
+		for _, count := range recorder.fired {
+			So(count, ShouldEqual, 1)
+		}
+
+		instances, err := store.GetInstances(clusterSName)
+		So(err, ShouldBeNil)
+		So(instances, ShouldBeEmpty)
+	})
+}
+
+func TestCluster_DeadInstanceExecutions(t *testing.T) {
+	Convey("Running executions of dead instance must be abandoned", t, func() {
+		store := stores.NewInMemoryStore()
+		fake := clock.NewFake(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+		for _, execution := range []executions.Execution{
+			{ID: "e1", JobKey: "j1", InstanceID: "dead", StartedAt: fake.Now().Add(-time.Hour), Status: executions.StatusRunning},
+			{ID: "e2", JobKey: "j1", InstanceID: "dead", StartedAt: fake.Now().Add(-time.Hour), Status: executions.StatusSucceeded},
+			{ID: "e3", JobKey: "j1", InstanceID: "live", StartedAt: fake.Now().Add(-time.Hour), Status: executions.StatusRunning},
+			{ID: "e4", JobKey: "j1", InstanceID: "node0", StartedAt: fake.Now().Add(-time.Hour), Status: executions.StatusRunning},
+		} {
+			So(store.(stores.ExecutionStore).InsertExecution(clusterSName, execution), ShouldBeNil)
+		}
+		So(store.Heartbeat(clusterSName, "dead", fake.Now().Add(-time.Minute)), ShouldBeNil)
+		So(store.Heartbeat(clusterSName, "live", fake.Now()), ShouldBeNil)
+
+		s := NewScheduler(
+			clusterSName,
+			WithStore(store),
+			WithClustering(),
+			WithInstanceID("node0"),
+			WithTimers(clusterTimers),
+			WithClock(fake),
+		)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		for id, status := range map[string]executions.Status{
+			"e1": executions.StatusAbandoned,
+			"e2": executions.StatusSucceeded,
+			"e3": executions.StatusRunning,
+			//left running by previous run of this instance
+			"e4": executions.StatusAbandoned,
+		} {
+			execution, err := s.GetExecution(id)
+			So(err, ShouldBeNil)
+			So(fmt.Sprintf("%s:%s", id, execution.Status), ShouldEqual, fmt.Sprintf("%s:%s", id, status))
+		}
+		execution, err := s.GetExecution("e1")
+		So(err, ShouldBeNil)
+		So(execution.FinishedAt.Equal(fake.Now()), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDisallowConcurrentExecution(t *testing.T) {
+	Convey("Job must not be executed concurrently across triggers and instances", t, func() {
+		store := stores.NewInMemoryStore()
+		fake := clock.NewFake(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+		j, err := NewJob().WithKey("j1").WithType("type").DisallowConcurrentExecution().ToImmutable()
+		So(err, ShouldBeNil)
+		So(store.InsertJob(clusterSName, j), ShouldBeNil)
+		for i := 0; i < 4; i++ {
+			tr, err := NewTrigger().WithKey(fmt.Sprintf("t%d", i)).WithCron("* * * * * *").InLocation("UTC").ToImmutable()
+			So(err, ShouldBeNil)
+			tr = internal.ModifyTrigger(tr, func(tr *internal.Trigger) {
+				tr.TjobKey = "j1"
+				tr.Tstate = triggers.StateScheduled
+				tr.TnextTime = fake.Now().Add(time.Second)
+			})
+			So(store.InsertTrigger(clusterSName, tr), ShouldBeNil)
+		}
+
+		var running, overlapped, fired int32
+		nodes := make([]Scheduler, 0, 2)
+		for i := 0; i < 2; i++ {
+			s := NewScheduler(
+				clusterSName,
+				WithStore(store),
+				WithClustering(),
+				WithInstanceID(fmt.Sprintf("node%d", i)),
+				WithTimers(steppedClusterTimers),
+				WithClock(fake),
+				WithConcurrentExecutionPolicy(ConcurrentExecutionSkip),
+			)
+			s.RegisterExecutor("type", func(ctx JobContext) error {
+				if atomic.AddInt32(&running, 1) > 1 {
+					atomic.AddInt32(&overlapped, 1)
+				}
+				<-fake.After(300 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				atomic.AddInt32(&fired, 1)
+				return nil
+			})
+			nodes = append(nodes, s)
+		}
+
+		for _, n := range nodes {
+			n.Start()
+		}
+		advanceUntil(fake, func() bool { return atomic.LoadInt32(&fired) >= 3 })
+		//running job waits for fake clock, so it is advanced until shutdown returns
+		for _, n := range nodes {
+			done := make(chan error, 1)
+			go func(n Scheduler) {
+				done <- n.Shutdown(context.Background())
+			}(n)
+			advanceUntil(fake, func() bool { return len(done) > 0 })
+			So(<-done, ShouldBeNil)
+		}
+
+		So(atomic.LoadInt32(&fired), ShouldBeGreaterThanOrEqualTo, 3)
+		So(atomic.LoadInt32(&overlapped), ShouldEqual, 0)
+
+		ok, err := store.LockJob(clusterSName, "j1", "other", fake.Now())
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+	})
+}
+
+func TestDisallowConcurrentExecution_CrashedInstance(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{
+		TriggerStealTimeout:     time.Second,
+		TriggerRecoveryInterval: 10 * time.Second,
+		TriggerRecoveryTimeout:  30 * time.Second,
+	}
+
+	for _, policy := range []ConcurrentExecutionPolicy{ConcurrentExecutionBlock, ConcurrentExecutionSkip} {
+		Convey(fmt.Sprintf("Job locked by crashed instance must be executed after lock expiration with %v policy", policy), t, func() {
+			fake := clock.NewFake(start)
+			store := stores.NewInMemoryStore()
+			fired := make(chan time.Time, 10)
+			s := NewScheduler(
+				"crashed",
+				WithStore(store),
+				WithClock(fake),
+				WithTimers(timers),
+				WithConcurrentExecutionPolicy(policy),
+			)
+			s.RegisterExecutor("type", func(ctx JobContext) error {
+				fired <- fake.Now()
+				return nil
+			})
+			So(s.ScheduleJob(
+				NewJob().WithKey("j1").WithType("type").DisallowConcurrentExecution(),
+				NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+			), ShouldBeNil)
+
+			//instance crashed while running job and has never released its lock
+			ok, err := store.LockJob("crashed", "j1", "crashed", start)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			s.Start()
+			defer s.Shutdown(context.Background())
+
+			var firedAt time.Time
+			for i := 0; i < 180 && firedAt.IsZero(); i++ {
+				fake.Advance(time.Second)
+				select {
+				case firedAt = <-fired:
+				case <-time.After(time.Millisecond):
+				}
+			}
+
+			So(firedAt.IsZero(), ShouldBeFalse)
+			So(firedAt.Before(start.Add(timers.TriggerRecoveryTimeout)), ShouldBeFalse)
+			So(firedAt.Sub(start), ShouldBeLessThan, 2*time.Minute)
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package cronexpr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	kindSecond = fieldKind(iota)
+	kindMinute
+	kindHour
+	kindDayOfMonth
+	kindMonth
+	kindDayOfWeek
+	kindYear
+)
+
+// years after last matching one searched by single Expression.Next call if spec has no year field
+const maxSearchYears = 50
+
+var ErrEmptySpec = errors.New("empty cron spec")
+
+var (
+	secondField     = field{kind: kindSecond, name: "second", min: 0, max: 59}
+	minuteField     = field{kind: kindMinute, name: "minute", min: 0, max: 59}
+	hourField       = field{kind: kindHour, name: "hour", min: 0, max: 23}
+	dayOfMonthField = field{kind: kindDayOfMonth, name: "day of month", min: 1, max: 31}
+	monthField      = field{kind: kindMonth, name: "month", min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	//0 and 7 are Sunday
+	dayOfWeekField = field{kind: kindDayOfWeek, name: "day of week", min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+	//1 is Sunday like in Quartz
+	quartzDayOfWeekField = field{kind: kindDayOfWeek, name: "day of week", min: 1, max: 7, names: map[string]int{
+		"SUN": 1, "MON": 2, "TUE": 3, "WED": 4, "THU": 5, "FRI": 6, "SAT": 7,
+	}}
+	yearField = field{kind: kindYear, name: "year", min: 1970, max: 2099}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 0 1 1 ?",
+	"@annually": "0 0 0 1 1 ?",
+	"@monthly":  "0 0 0 1 * ?",
+	"@weekly":   "0 0 0 ? * 1",
+	"@daily":    "0 0 0 * * ?",
+	"@midnight": "0 0 0 * * ?",
+	"@hourly":   "0 0 * * * ?",
+}
+
+type fieldKind int
+
+type field struct {
+	kind     fieldKind
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+// field of spec with its 0-based offset
+type token struct {
+	text string
+	pos  int
+}
+
+// nth weekday of month, e.g. third Friday
+type nthWeekday struct {
+	day time.Weekday
+	n   int
+}
+
+// error of spec parsing, position is 1-based offset of invalid part in spec
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+// parsed cron spec, calculates fire times by wall clock fields
+type Expression struct {
+	second, minute, hour, month uint64
+	dayOfMonth, dayOfWeek       uint64
+	// indexed from first year of year field, nil means every year
+	years    []bool
+	lastYear int
+	// day of month and day of week fields are "*" or "?"
+	anyDayOfMonth, anyDayOfWeek bool
+	// offsets before last day of month of "L" and "L-n"
+	lastDays []int
+	// "LW", last weekday of month
+	lastWorkday bool
+	// days of "nW", weekdays nearest to them within month
+	nearestWorkdays []int
+	// weekdays of "nL", last such weekday of month
+	lastWeekdays uint64
+	// "n#k"
+	nthWeekdays []nthWeekday
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// parse cron spec of 5 to 7 space separated fields:
+//
+//	minute hour day-of-month month day-of-week
+//	second minute hour day-of-month month day-of-week [year]
+//
+// 5 field spec follows standard cron where day of week is 0-7 and both 0 and 7 are Sunday.
+// 6 and 7 field specs follow Quartz where day of week is 1-7 and 1 is Sunday.
+// fields accept "*", values, names like JAN or MON, ranges "a-b" which may wrap around, steps "*/n", "a/n"
+// and "a-b/n", and lists of them. "?" means any day in day of month and day of week fields.
+// day of month also accepts "L" (last day), "L-n" (n days before last day), "LW" (last weekday)
+// and "nW" (weekday nearest to day n within month).
+// day of week also accepts "L" (Saturday), "nL" (last such weekday of month) and "n#k" (k-th such weekday of month).
+// if both day of month and day of week are restricted, day matching either of them fires.
+// macros @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are accepted too
+func Parse(spec string) (*Expression, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, ErrEmptySpec
+	}
+	if trimmed := strings.TrimSpace(spec); strings.HasPrefix(trimmed, "@") {
+		expanded, ok := macros[strings.ToLower(trimmed)]
+		if !ok {
+			return nil, &SyntaxError{Pos: strings.Index(spec, "@") + 1, Msg: fmt.Sprintf("unknown macro %q", trimmed)}
+		}
+		spec = expanded
+	}
+
+	tokens := splitFields(spec)
+	var fields []field
+	switch len(tokens) {
+	case 5:
+		fields = []field{minuteField, hourField, dayOfMonthField, monthField, dayOfWeekField}
+	case 6:
+		fields = []field{secondField, minuteField, hourField, dayOfMonthField, monthField, quartzDayOfWeekField}
+	case 7:
+		fields = []field{secondField, minuteField, hourField, dayOfMonthField, monthField, quartzDayOfWeekField, yearField}
+	default:
+		if len(tokens) < 5 {
+			return nil, &SyntaxError{Pos: len(spec) + 1, Msg: fmt.Sprintf("expected 5 to 7 fields, got %d", len(tokens))}
+		}
+		return nil, &SyntaxError{Pos: tokens[7].pos + 1, Msg: fmt.Sprintf("expected 5 to 7 fields, got %d", len(tokens))}
+	}
+	//second is zero if not given
+	e := &Expression{second: 1}
+
+	for i, f := range fields {
+		if err := e.parseField(f, tokens[i]); err != nil {
+			return nil, err
+		}
+	}
+	e.lastYear = -1
+	for i, ok := range e.years {
+		if ok {
+			e.lastYear = yearField.min + i
+		}
+	}
+
+	return e, nil
+}
+
+func splitFields(spec string) []token {
+	var arr []token
+	start := -1
+	for i := 0; i <= len(spec); i++ {
+		if i == len(spec) || spec[i] == ' ' || spec[i] == '\t' {
+			if start >= 0 {
+				arr = append(arr, token{text: spec[start:i], pos: start})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	return arr
+}
+
+func (e *Expression) parseField(f field, t token) error {
+	if f.kind == kindSecond {
+		e.second = 0
+	}
+	if f.kind == kindYear {
+		e.years = make([]bool, f.max-f.min+1)
+	}
+	text := strings.ToUpper(t.text)
+
+	switch text {
+	case "?":
+		if f.kind != kindDayOfMonth && f.kind != kindDayOfWeek {
+			return &SyntaxError{Pos: t.pos + 1, Msg: fmt.Sprintf("\"?\" is not allowed in %s field", f.name)}
+		}
+		fallthrough
+	case "*":
+		switch f.kind {
+		case kindDayOfMonth:
+			e.anyDayOfMonth = true
+		case kindDayOfWeek:
+			e.anyDayOfWeek = true
+		case kindYear:
+			e.years = nil
+			return nil
+		}
+		e.addRange(f, f.min, f.max, 1)
+		return nil
+	}
+
+	pos := t.pos
+	for _, item := range strings.Split(text, ",") {
+		if item == "" {
+			return &SyntaxError{Pos: pos + 1, Msg: fmt.Sprintf("empty list item of %s field", f.name)}
+		}
+		if err := e.parseItem(f, item, pos); err != nil {
+			return err
+		}
+		pos += len(item) + 1
+	}
+	return nil
+}
+
+func (e *Expression) parseItem(f field, item string, pos int) error {
+	if item == "?" {
+		return &SyntaxError{Pos: pos + 1, Msg: fmt.Sprintf("\"?\" must be the only item of %s field", f.name)}
+	}
+	switch f.kind {
+	case kindDayOfMonth:
+		if ok, err := e.parseDayOfMonthModifier(item, pos); ok || err != nil {
+			return err
+		}
+	case kindDayOfWeek:
+		if ok, err := e.parseDayOfWeekModifier(f, item, pos); ok || err != nil {
+			return err
+		}
+	}
+
+	rangePart, step := item, 1
+	if i := strings.IndexByte(item, '/'); i >= 0 {
+		rangePart = item[:i]
+		n, err := strconv.Atoi(item[i+1:])
+		if err != nil || n <= 0 || n > f.max-f.min+1 {
+			return &SyntaxError{
+				Pos: pos + i + 2,
+				Msg: fmt.Sprintf("invalid step %q of %s field, must be 1-%d", item[i+1:], f.name, f.max-f.min+1),
+			}
+		}
+		step = n
+	}
+
+	var (
+		from, to int
+		err      error
+	)
+	if rangePart == "*" {
+		from, to = f.min, f.max
+	} else if i := strings.IndexByte(rangePart, '-'); i >= 0 {
+		if from, err = parseValue(f, rangePart[:i], pos); err != nil {
+			return err
+		}
+		if to, err = parseValue(f, rangePart[i+1:], pos+i+1); err != nil {
+			return err
+		}
+	} else {
+		if from, err = parseValue(f, rangePart, pos); err != nil {
+			return err
+		}
+		to = from
+		//"a/n" is "a-max/n"
+		if len(rangePart) < len(item) {
+			to = f.max
+		}
+	}
+	e.addRange(f, from, to, step)
+	return nil
+}
+
+// parse "L", "L-n", "LW" and "nW" items of day of month field, false if item is not one of them
+func (e *Expression) parseDayOfMonthModifier(item string, pos int) (bool, error) {
+	switch {
+	case item == "L":
+		e.lastDays = append(e.lastDays, 0)
+	case item == "LW":
+		e.lastWorkday = true
+	case strings.HasPrefix(item, "L-"):
+		n, err := strconv.Atoi(item[2:])
+		if err != nil || n < 0 || n > 30 {
+			return true, &SyntaxError{Pos: pos + 3, Msg: fmt.Sprintf("invalid offset %q of \"L-n\", must be 0-30", item[2:])}
+		}
+		e.lastDays = append(e.lastDays, n)
+	case strings.HasSuffix(item, "W"):
+		day, err := parseValue(dayOfMonthField, item[:len(item)-1], pos)
+		if err != nil {
+			return true, err
+		}
+		e.nearestWorkdays = append(e.nearestWorkdays, day)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// parse "L", "nL" and "n#k" items of day of week field, false if item is not one of them
+func (e *Expression) parseDayOfWeekModifier(f field, item string, pos int) (bool, error) {
+	switch {
+	case item == "L":
+		e.dayOfWeek |= 1 << uint(time.Saturday)
+	case strings.HasSuffix(item, "L"):
+		v, err := parseValue(f, item[:len(item)-1], pos)
+		if err != nil {
+			return true, err
+		}
+		e.lastWeekdays |= 1 << uint(weekdayOf(f, v))
+	case strings.Contains(item, "#"):
+		i := strings.IndexByte(item, '#')
+		v, err := parseValue(f, item[:i], pos)
+		if err != nil {
+			return true, err
+		}
+		n, err := strconv.Atoi(item[i+1:])
+		if err != nil || n < 1 || n > 5 {
+			return true, &SyntaxError{Pos: pos + i + 2, Msg: fmt.Sprintf("invalid occurrence %q of \"n#k\", must be 1-5", item[i+1:])}
+		}
+		e.nthWeekdays = append(e.nthWeekdays, nthWeekday{day: weekdayOf(f, v), n: n})
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func parseValue(f field, s string, pos int) (int, error) {
+	v, ok := f.names[s]
+	if !ok {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, &SyntaxError{Pos: pos + 1, Msg: fmt.Sprintf("invalid value %q of %s field", s, f.name)}
+		}
+		v = n
+	}
+	if v < f.min || v > f.max {
+		return 0, &SyntaxError{
+			Pos: pos + 1,
+			Msg: fmt.Sprintf("value %s of %s field is out of range %d-%d", s, f.name, f.min, f.max),
+		}
+	}
+	return v, nil
+}
+
+// add every step value from first to last, range wraps around max value if first is greater than last
+func (e *Expression) addRange(f field, first, last, step int) {
+	span := last - first + 1
+	if first > last {
+		span += f.max - f.min + 1
+	}
+	for i := 0; i < span; i += step {
+		v := first + i
+		if v > f.max {
+			v -= f.max - f.min + 1
+		}
+		switch f.kind {
+		case kindSecond:
+			e.second |= 1 << uint(v)
+		case kindMinute:
+			e.minute |= 1 << uint(v)
+		case kindHour:
+			e.hour |= 1 << uint(v)
+		case kindDayOfMonth:
+			e.dayOfMonth |= 1 << uint(v)
+		case kindMonth:
+			e.month |= 1 << uint(v)
+		case kindDayOfWeek:
+			e.dayOfWeek |= 1 << uint(weekdayOf(f, v))
+		case kindYear:
+			e.years[v-f.min] = true
+		}
+	}
+}
+
+func weekdayOf(f field, v int) time.Weekday {
+	return time.Weekday((v - f.names["SUN"]) % 7)
+}
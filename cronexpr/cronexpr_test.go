@@ -0,0 +1,168 @@
+package cronexpr
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func fireTimes(t *testing.T, spec string, after time.Time, n int) []string {
+	expr, err := Parse(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	arr := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		next := expr.Next(after)
+		if next.IsZero() {
+			break
+		}
+		arr = append(arr, next.Format("2006-01-02 Mon 15:04:05"))
+		after = next
+	}
+	return arr
+}
+
+func TestExpression_Next(t *testing.T) {
+	//2030-01-01 is Tuesday
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	Convey("Test standard and Quartz fields", t, func() {
+		Convey("5 field spec has no seconds and 0 and 7 are Sunday", func() {
+			So(fireTimes(t, "30 9 * * 0,6-7", start, 3), ShouldResemble, []string{
+				"2030-01-05 Sat 09:30:00", "2030-01-06 Sun 09:30:00", "2030-01-12 Sat 09:30:00",
+			})
+		})
+
+		Convey("6 field spec has seconds and 1 is Sunday", func() {
+			So(fireTimes(t, "*/20 0 12 ? * 1", start, 4), ShouldResemble, []string{
+				"2030-01-06 Sun 12:00:00", "2030-01-06 Sun 12:00:20", "2030-01-06 Sun 12:00:40", "2030-01-13 Sun 12:00:00",
+			})
+		})
+
+		Convey("7 field spec has years", func() {
+			So(fireTimes(t, "0 0 0 29 FEB ? 2030-2033,2040", start, 5), ShouldResemble, []string{
+				"2032-02-29 Sun 00:00:00", "2040-02-29 Wed 00:00:00",
+			})
+		})
+
+		Convey("names, steps and wrapping ranges", func() {
+			So(fireTimes(t, "0 10/20 22-1/2 ? nov-feb SAT-mon", start, 6), ShouldResemble, []string{
+				"2030-01-05 Sat 00:10:00", "2030-01-05 Sat 00:30:00", "2030-01-05 Sat 00:50:00",
+				"2030-01-05 Sat 22:10:00", "2030-01-05 Sat 22:30:00", "2030-01-05 Sat 22:50:00",
+			})
+		})
+
+		Convey("restricted day of month and day of week are combined by or", func() {
+			So(fireTimes(t, "0 0 13 * 5", start, 3), ShouldResemble, []string{
+				"2030-01-04 Fri 00:00:00", "2030-01-11 Fri 00:00:00", "2030-01-13 Sun 00:00:00",
+			})
+		})
+
+		Convey("macros", func() {
+			So(fireTimes(t, "@weekly", start, 1), ShouldResemble, []string{"2030-01-06 Sun 00:00:00"})
+			So(fireTimes(t, "@monthly", start, 1), ShouldResemble, []string{"2030-02-01 Fri 00:00:00"})
+		})
+	})
+
+	Convey("Test modifiers", t, func() {
+		Convey("last day of month", func() {
+			So(fireTimes(t, "0 0 18 L * ?", start, 3), ShouldResemble, []string{
+				"2030-01-31 Thu 18:00:00", "2030-02-28 Thu 18:00:00", "2030-03-31 Sun 18:00:00",
+			})
+			So(fireTimes(t, "0 0 18 L-3 * ?", start, 2), ShouldResemble, []string{
+				"2030-01-28 Mon 18:00:00", "2030-02-25 Mon 18:00:00",
+			})
+		})
+
+		Convey("last weekday of month", func() {
+			So(fireTimes(t, "0 0 18 LW * ?", start, 3), ShouldResemble, []string{
+				"2030-01-31 Thu 18:00:00", "2030-02-28 Thu 18:00:00", "2030-03-29 Fri 18:00:00",
+			})
+		})
+
+		Convey("nearest weekday must not cross month", func() {
+			//2030-06-01 is Saturday, 2030-03-31 is Sunday
+			So(fireTimes(t, "0 0 9 1W,15W 6 ?", start, 2), ShouldResemble, []string{
+				"2030-06-03 Mon 09:00:00", "2030-06-14 Fri 09:00:00",
+			})
+			So(fireTimes(t, "0 0 9 31W 3,4 ?", start, 2), ShouldResemble, []string{
+				"2030-03-29 Fri 09:00:00", "2031-03-31 Mon 09:00:00",
+			})
+		})
+
+		Convey("last weekday of month by day of week", func() {
+			So(fireTimes(t, "0 0 9 ? * 6L", start, 2), ShouldResemble, []string{
+				"2030-01-25 Fri 09:00:00", "2030-02-22 Fri 09:00:00",
+			})
+			So(fireTimes(t, "0 9 * * FRIL", start, 1), ShouldResemble, []string{"2030-01-25 Fri 09:00:00"})
+		})
+
+		Convey("nth weekday of month", func() {
+			So(fireTimes(t, "0 15 10 ? * 6#3,2#1", start, 3), ShouldResemble, []string{
+				"2030-01-07 Mon 10:15:00", "2030-01-18 Fri 10:15:00", "2030-02-04 Mon 10:15:00",
+			})
+			So(fireTimes(t, "0 0 0 ? * 3#5", start, 2), ShouldResemble, []string{
+				"2030-01-29 Tue 00:00:00", "2030-04-30 Tue 00:00:00",
+			})
+		})
+	})
+
+	Convey("Test next time", t, func() {
+		Convey("must be strictly after given time", func() {
+			expr, err := Parse("0 0 * * * ?")
+			So(err, ShouldBeNil)
+			So(expr.Next(start).Equal(start.Add(time.Hour)), ShouldBeTrue)
+			So(expr.Next(start.Add(-time.Nanosecond)).Equal(start), ShouldBeTrue)
+		})
+
+		Convey("must be in location of given time", func() {
+			tokyo, err := time.LoadLocation("Asia/Tokyo")
+			So(err, ShouldBeNil)
+			expr, err := Parse("0 0 9 * * ?")
+			So(err, ShouldBeNil)
+			next := expr.Next(time.Date(2030, 1, 1, 10, 0, 0, 0, tokyo))
+			So(next.Location(), ShouldEqual, tokyo)
+			So(next.Equal(time.Date(2030, 1, 2, 9, 0, 0, 0, tokyo)), ShouldBeTrue)
+		})
+
+		Convey("must be zero if expression never matches", func() {
+			So(fireTimes(t, "0 0 0 30 2 ?", start, 1), ShouldBeEmpty)
+			So(fireTimes(t, "0 0 0 * * ? 2029", start, 1), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestParse(t *testing.T) {
+	Convey("Invalid specs must be rejected with position of error", t, func() {
+		for spec, pos := range map[string]int{
+			"* * * *":                  8,
+			"0 0 0 * * ? 2030 extra":   18,
+			"0 60 * * * ?":             3,
+			"0 0 12 1,,2 * ?":          10,
+			"0 0 12 32 * ?":            8,
+			"0 0 12 L-31 * ?":          10,
+			"0 0 12 0W * ?":            8,
+			"0 0 12 ? FOO *":           10,
+			"0 0 12 ? * MON#6":         16,
+			"0 0 12 ? * 8L":            12,
+			"0 0 12 ? * 1,?":           14,
+			"0 */0 12 * * ?":           5,
+			"0 0 12-x * * ?":           8,
+			"? 0 12 * * *":             1,
+			"0 0 12 * * ? 1969":        14,
+			"  @fortnightly":           3,
+			"0 0 0 1 JAN ? 2030-2100/": 25,
+		} {
+			_, err := Parse(spec)
+			So(err, ShouldHaveSameTypeAs, &SyntaxError{})
+			So(err.(*SyntaxError).Pos, ShouldEqual, pos)
+		}
+
+		_, err := Parse(" ")
+		So(err, ShouldEqual, ErrEmptySpec)
+		_, err = Parse("0 0 12 32 * ?")
+		So(err.Error(), ShouldEqual, "value 32 of day of month field is out of range 1-31 at position 8")
+	})
+}
@@ -0,0 +1,141 @@
+package cronexpr
+
+import "time"
+
+// first time strictly after given one which matches expression. fields are matched against wall clock
+// of given time in its location, zero if never matches
+func (e *Expression) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := time.Date(after.Year(), after.Month(), after.Day(), after.Hour(), after.Minute(), after.Second()+1, 0, time.UTC)
+	lastYear := e.lastYear
+	if e.years == nil {
+		lastYear = t.Year() + maxSearchYears
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	hour, minute, second := t.Clock()
+	for day.Year() <= lastYear {
+		y, m, d := day.Date()
+		switch {
+		case !e.matchYear(y):
+			day = time.Date(y+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		case !has(e.month, int(m)):
+			day = time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+		case e.matchDay(y, m, d):
+			if h, mi, s, ok := e.nextInDay(hour, minute, second); ok {
+				return time.Date(y, m, d, h, mi, s, 0, loc)
+			}
+			fallthrough
+		default:
+			day = day.AddDate(0, 0, 1)
+		}
+		hour, minute, second = 0, 0, 0
+	}
+
+	return time.Time{}
+}
+
+func (e *Expression) matchYear(year int) bool {
+	return e.years == nil || year >= yearField.min && year <= yearField.max && e.years[year-yearField.min]
+}
+
+// day of month and day of week fields are combined like in standard cron: if one of them is unrestricted,
+// the other one must match, otherwise either of them
+func (e *Expression) matchDay(year int, month time.Month, day int) bool {
+	switch {
+	case e.anyDayOfMonth && e.anyDayOfWeek:
+		return true
+	case e.anyDayOfMonth:
+		return e.matchDayOfWeek(year, month, day)
+	case e.anyDayOfWeek:
+		return e.matchDayOfMonth(year, month, day)
+	default:
+		return e.matchDayOfMonth(year, month, day) || e.matchDayOfWeek(year, month, day)
+	}
+}
+
+func (e *Expression) matchDayOfMonth(year int, month time.Month, day int) bool {
+	if has(e.dayOfMonth, day) {
+		return true
+	}
+	last := daysIn(year, month)
+	for _, offset := range e.lastDays {
+		if day == last-offset {
+			return true
+		}
+	}
+	if e.lastWorkday && day == nearestWorkday(year, month, last) {
+		return true
+	}
+	for _, d := range e.nearestWorkdays {
+		if d <= last && day == nearestWorkday(year, month, d) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Expression) matchDayOfWeek(year int, month time.Month, day int) bool {
+	wd := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+	if has(e.dayOfWeek, int(wd)) {
+		return true
+	}
+	if has(e.lastWeekdays, int(wd)) && day+7 > daysIn(year, month) {
+		return true
+	}
+	for _, nth := range e.nthWeekdays {
+		if nth.day == wd && (day-1)/7+1 == nth.n {
+			return true
+		}
+	}
+	return false
+}
+
+// first matching time of day not before given one
+func (e *Expression) nextInDay(hour, minute, second int) (int, int, int, bool) {
+	for h := hour; h < 24; h++ {
+		if !has(e.hour, h) {
+			minute, second = 0, 0
+			continue
+		}
+		for m := minute; m < 60; m++ {
+			if !has(e.minute, m) {
+				second = 0
+				continue
+			}
+			for s := second; s < 60; s++ {
+				if has(e.second, s) {
+					return h, m, s, true
+				}
+			}
+			second = 0
+		}
+		minute, second = 0, 0
+	}
+	return 0, 0, 0, false
+}
+
+// weekday nearest to given day within month, like Quartz "nW"
+func nearestWorkday(year int, month time.Month, day int) int {
+	switch time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return day + 2
+		}
+		return day - 1
+	case time.Sunday:
+		if day == daysIn(year, month) {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"fmt"
+	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestTrigger_DSTPolicy(t *testing.T) {
+	zone := func(name string) *time.Location {
+		loc, err := time.LoadLocation(name)
+		So(err, ShouldBeNil)
+		return loc
+	}
+	//first n fire times of trigger after given time
+	fireTimes := func(tr triggers.MutableTrigger, after time.Time, n int) []string {
+		immutable, err := tr.(*internal.Trigger).ToImmutableAt(after)
+		So(err, ShouldBeNil)
+		arr := make([]string, 0, n)
+		for next := immutable.NextTriggerTime(); len(arr) < n && !next.IsZero(); {
+			arr = append(arr, next.Format(time.RFC3339))
+			next = internal.CalcNextTriggerTime(immutable.(*internal.Trigger), next)
+		}
+		return arr
+	}
+	cron := func(spec, loc string, gap triggers.DSTGapPolicy, overlap triggers.DSTOverlapPolicy) triggers.MutableTrigger {
+		return NewTrigger().WithKey("t1").WithCron(spec).InLocation(loc).WithDSTPolicy(gap, overlap)
+	}
+
+	Convey("Wall clock time within spring-forward gap", t, func() {
+		ny := zone("America/New_York")
+		after := time.Date(2030, 3, 9, 0, 0, 0, 0, ny)
+
+		Convey("must be shifted forward by default", func() {
+			So(fireTimes(cron("0 30 2 * * *", "America/New_York", "", ""), after, 3), ShouldResemble, []string{
+				"2030-03-09T02:30:00-05:00", "2030-03-10T03:30:00-04:00", "2030-03-11T02:30:00-04:00",
+			})
+		})
+
+		Convey("must be skipped by skip policy", func() {
+			So(fireTimes(cron("0 30 2 * * *", "America/New_York", triggers.DSTGapSkip, ""), after, 2), ShouldResemble, []string{
+				"2030-03-09T02:30:00-05:00", "2030-03-11T02:30:00-04:00",
+			})
+		})
+
+		Convey("must not be fired twice if shifted to existing time", func() {
+			from := time.Date(2030, 3, 10, 0, 30, 0, 0, ny)
+			So(fireTimes(cron("0 0 * * * *", "America/New_York", "", ""), from, 3), ShouldResemble, []string{
+				"2030-03-10T01:00:00-05:00", "2030-03-10T03:00:00-04:00", "2030-03-10T04:00:00-04:00",
+			})
+		})
+
+		Convey("must be shifted by length of gap", func() {
+			//Lord Howe Island shifts clocks by half an hour
+			from := time.Date(2030, 10, 5, 12, 0, 0, 0, time.UTC)
+			So(fireTimes(cron("0 15 2 * * *", "Australia/Lord_Howe", "", ""), from, 2), ShouldResemble, []string{
+				"2030-10-06T02:45:00+11:00", "2030-10-07T02:15:00+11:00",
+			})
+		})
+
+		Convey("must be handled in southern hemisphere and by daily interval trigger", func() {
+			from := time.Date(2030, 10, 5, 12, 0, 0, 0, time.UTC)
+			window := func(gap triggers.DSTGapPolicy) triggers.MutableTrigger {
+				return NewTrigger().WithKey("t1").WithDailyInterval("02:00", "02:30", 15*time.Minute).
+					InLocation("Australia/Sydney").WithDSTPolicy(gap, "")
+			}
+			So(fireTimes(window(""), from, 4), ShouldResemble, []string{
+				"2030-10-06T03:00:00+11:00", "2030-10-06T03:15:00+11:00", "2030-10-06T03:30:00+11:00",
+				"2030-10-07T02:00:00+11:00",
+			})
+			So(fireTimes(window(triggers.DSTGapSkip), from, 1), ShouldResemble, []string{"2030-10-07T02:00:00+11:00"})
+		})
+	})
+
+	Convey("Wall clock time repeated by fall-back overlap", t, func() {
+		Convey("must be fired once by default", func() {
+			after := time.Date(2030, 11, 2, 0, 0, 0, 0, zone("America/New_York"))
+			So(fireTimes(cron("0 30 1 * * *", "America/New_York", "", ""), after, 3), ShouldResemble, []string{
+				"2030-11-02T01:30:00-04:00", "2030-11-03T01:30:00-04:00", "2030-11-04T01:30:00-05:00",
+			})
+		})
+
+		Convey("must be fired twice by twice policy", func() {
+			after := time.Date(2030, 10, 26, 0, 0, 0, 0, zone("Europe/Berlin"))
+			So(fireTimes(cron("0 30 2 * * *", "Europe/Berlin", "", triggers.DSTOverlapTwice), after, 4), ShouldResemble, []string{
+				"2030-10-26T02:30:00+02:00", "2030-10-27T02:30:00+02:00", "2030-10-27T02:30:00+01:00",
+				"2030-10-28T02:30:00+01:00",
+			})
+		})
+
+		Convey("must be fired by every hour trigger in order", func() {
+			from := time.Date(2030, 11, 3, 0, 30, 0, 0, zone("America/New_York"))
+			So(fireTimes(cron("0 0 * * * *", "America/New_York", "", ""), from, 3), ShouldResemble, []string{
+				"2030-11-03T01:00:00-04:00", "2030-11-03T02:00:00-05:00", "2030-11-03T03:00:00-05:00",
+			})
+			So(fireTimes(cron("0 0 * * * *", "America/New_York", "", triggers.DSTOverlapTwice), from, 4), ShouldResemble, []string{
+				"2030-11-03T01:00:00-04:00", "2030-11-03T01:00:00-05:00", "2030-11-03T02:00:00-05:00",
+				"2030-11-03T03:00:00-05:00",
+			})
+		})
+
+		Convey("must be handled in southern hemisphere and by daily interval trigger", func() {
+			from := time.Date(2030, 4, 6, 12, 0, 0, 0, time.UTC)
+			So(fireTimes(NewTrigger().WithKey("t1").WithDailyInterval("02:15", "02:45", 30*time.Minute).
+				InLocation("Australia/Sydney").WithDSTPolicy("", triggers.DSTOverlapTwice), from, 5), ShouldResemble, []string{
+				"2030-04-07T02:15:00+11:00", "2030-04-07T02:45:00+11:00",
+				"2030-04-07T02:15:00+10:00", "2030-04-07T02:45:00+10:00",
+				"2030-04-08T02:15:00+10:00",
+			})
+			So(fireTimes(cron("0 45 1 * * *", "Australia/Lord_Howe", "", triggers.DSTOverlapTwice), from, 3), ShouldResemble, []string{
+				"2030-04-07T01:45:00+11:00", "2030-04-07T01:45:00+10:30", "2030-04-08T01:45:00+10:30",
+			})
+		})
+	})
+
+	Convey("Triggers not defined by wall clock must not depend on DST policy", t, func() {
+		ny := zone("America/New_York")
+		from := time.Date(2030, 3, 10, 0, 30, 0, 0, ny)
+		So(fireTimes(cron("@every 1h", "America/New_York", triggers.DSTGapSkip, ""), from, 3), ShouldResemble, []string{
+			"2030-03-10T01:30:00-05:00", "2030-03-10T03:30:00-04:00", "2030-03-10T04:30:00-04:00",
+		})
+
+		Convey("recurrence rule trigger must follow RFC 5545", func() {
+			rrule := func(spec string, from time.Time) triggers.MutableTrigger {
+				return NewTrigger().WithKey("t1").WithRRule(spec).WithFromTime(from).InLocation("America/New_York")
+			}
+			So(fireTimes(rrule("FREQ=DAILY;BYHOUR=2;BYMINUTE=30;BYSECOND=0", time.Date(2030, 3, 9, 0, 0, 0, 0, ny)), from, 2),
+				ShouldResemble, []string{"2030-03-10T03:30:00-04:00", "2030-03-11T02:30:00-04:00"})
+			fallBack := time.Date(2030, 11, 3, 0, 0, 0, 0, ny)
+			So(fireTimes(rrule("FREQ=DAILY;BYHOUR=1;BYMINUTE=30;BYSECOND=0", fallBack), fallBack, 2),
+				ShouldResemble, []string{"2030-11-03T01:30:00-04:00", "2030-11-04T01:30:00-05:00"})
+		})
+	})
+
+	Convey("Invalid DST policy must be rejected", t, func() {
+		_, err := cron("0 0 * * * *", "UTC", "LATER", "").(*internal.Trigger).ToImmutableAt(time.Now())
+		So(err, ShouldResemble, fmt.Errorf(triggers.ErrInvalidDSTPolicy, "LATER"))
+		_, err = cron("0 0 * * * *", "UTC", "", "NEVER").(*internal.Trigger).ToImmutableAt(time.Now())
+		So(err, ShouldResemble, fmt.Errorf(triggers.ErrInvalidDSTPolicy, "NEVER"))
+	})
+}
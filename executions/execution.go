@@ -0,0 +1,70 @@
+package executions
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	StatusRunning   = Status("RUNNING")
+	StatusSucceeded = Status("SUCCEEDED")
+	StatusFailed    = Status("FAILED")
+	// execution was stopped by Scheduler.InterruptJob or Scheduler.InterruptExecution
+	StatusInterrupted = Status("INTERRUPTED")
+	// instance running execution has died before it finished
+	StatusAbandoned = Status("ABANDONED")
+)
+
+var (
+	ErrNotSupported = errors.New("store does not support execution history")
+)
+
+type Status string
+
+type Execution struct {
+	ID         string
+	JobKey     string
+	TriggerKey string
+	// id of scheduler instance which has run execution
+	InstanceID string
+	// number of attempt of trigger firing, starts from 1
+	Attempt    int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Status     Status
+	Error      string
+	// optional payload set by job executor
+	Result []byte
+	// interruption was requested by other scheduler instance
+	InterruptRequested bool
+}
+
+func (e *Execution) Duration() time.Duration {
+	if e.FinishedAt.IsZero() {
+		return 0
+	}
+	return e.FinishedAt.Sub(e.StartedAt)
+}
+
+// zero fields are not applied
+type Filter struct {
+	Status Status
+	// only executions started within [StartedFrom, StartedTo)
+	StartedFrom time.Time
+	StartedTo   time.Time
+	// max count of returned executions, newest first
+	Limit int
+}
+
+func (f Filter) Match(e Execution) bool {
+	if f.Status != "" && e.Status != f.Status {
+		return false
+	}
+	if !f.StartedFrom.IsZero() && e.StartedAt.Before(f.StartedFrom) {
+		return false
+	}
+	if !f.StartedTo.IsZero() && !e.StartedAt.Before(f.StartedTo) {
+		return false
+	}
+	return true
+}
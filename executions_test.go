@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/executions"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Executions(t *testing.T) {
+	Convey("Executions of job must be saved to history", t, func() {
+		start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+		timers := Timers{TriggerStealTimeout: time.Second}
+		fake := clock.NewFake(start)
+		var fired int32
+		s := NewScheduler("executions", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			if atomic.AddInt32(&fired, 1) == 1 {
+				return ctx.SetResult(map[string]int{"value": 1})
+			}
+			return errors.New("failed")
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("* * * * * *").InLocation("UTC").WithRepeats(2),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+		fireAt(s, fake, timers, "t1", start.Add(time.Second))
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+		fireAt(s, fake, timers, "t1", start.Add(2*time.Second))
+		So(waitFor(triggeredTimes(s, "t1", 2)), ShouldBeTrue)
+
+		arr, err := s.GetExecutions("j1", executions.Filter{})
+		So(err, ShouldBeNil)
+		So(arr, ShouldHaveLength, 2)
+
+		So(arr[0].Status, ShouldEqual, executions.StatusFailed)
+		So(arr[0].Error, ShouldEqual, "failed")
+		So(arr[0].TriggerKey, ShouldEqual, "t1")
+		So(arr[0].Attempt, ShouldEqual, 1)
+
+		So(arr[1].Status, ShouldEqual, executions.StatusSucceeded)
+		So(string(arr[1].Result), ShouldEqual, `{"value":1}`)
+		So(arr[1].StartedAt.Equal(start.Add(time.Second)), ShouldBeTrue)
+		So(arr[1].FinishedAt.Before(arr[1].StartedAt), ShouldBeFalse)
+
+		execution, err := s.GetExecution(arr[1].ID)
+		So(err, ShouldBeNil)
+		So(execution.JobKey, ShouldEqual, "j1")
+	})
+
+	Convey("Executions older than retention must be pruned from history", t, func() {
+		start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+		timers := Timers{
+			TriggerStealTimeout:     time.Second,
+			TriggerRecoveryInterval: 10 * time.Second,
+			ExecutionRetention:      time.Minute,
+		}
+		fireTime := start.Truncate(time.Minute).Add(time.Minute)
+		fake := clock.NewFake(start)
+		s := NewScheduler("executions", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC").WithRepeats(2),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		executionsCount := func(n int) func() bool {
+			return func() bool {
+				arr, err := s.GetExecutions("j1", executions.Filter{})
+				return err == nil && len(arr) == n
+			}
+		}
+
+		fireAt(s, fake, timers, "t1", fireTime)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+		fireAt(s, fake, timers, "t1", fireTime.Add(time.Minute))
+		So(waitFor(triggeredTimes(s, "t1", 2)), ShouldBeTrue)
+		So(waitFor(executionsCount(2)), ShouldBeTrue)
+
+		//first execution is pruned by next recovery tick
+		fake.Advance(timers.TriggerRecoveryInterval)
+		So(waitFor(executionsCount(1)), ShouldBeTrue)
+		arr, err := s.GetExecutions("j1", executions.Filter{})
+		So(err, ShouldBeNil)
+		So(arr[0].StartedAt.Equal(fireTime.Add(time.Minute)), ShouldBeTrue)
+
+		fake.Advance(timers.ExecutionRetention)
+		So(waitFor(executionsCount(0)), ShouldBeTrue)
+	})
+}
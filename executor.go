@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/executions"
 	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/jobs"
 	"github.com/d1slike/go-sched/log"
 	"github.com/d1slike/go-sched/stores"
 	"github.com/d1slike/go-sched/triggers"
@@ -14,30 +18,68 @@ import (
 )
 
 const (
-	defaultDelta = 10 * time.Second
+	// wait until previous execution of job is finished
+	ConcurrentExecutionBlock = ConcurrentExecutionPolicy("BLOCK")
+	// skip firing and reschedule trigger to its next fire time
+	ConcurrentExecutionSkip = ConcurrentExecutionPolicy("SKIP")
 )
 
 var (
 	ErrJobDeadlineExceeded = errors.New("job execution deadline exceeded")
+	ErrJobCanceled         = errors.New("job execution canceled")
+	ErrJobInterrupted      = errors.New("job execution interrupted")
 )
 
+type ConcurrentExecutionPolicy string
+
 type executor interface {
 	Start()
 	Shutdown(ctx context.Context) error
 	CancelTriggers(tKey ...string) int
+	// cancel future of trigger if it is not running, return false if trigger is running on this instance
+	CancelPendingTrigger(tKey string) bool
+	Stats() PoolStats
+	// interrupt executions running on this instance
+	InterruptJob(jKey string) int
+	InterruptExecution(id string) bool
 }
 
-type defaultRuntimeExecutor struct {
-	sName    string
-	store    stores.Store
-	registry executorRegistry
-	timers   Timers
+type executorOptions struct {
+	sName      string
+	instanceID string
+	clustered  bool
+	timers     Timers
+	misfire    triggers.MisfireInstruction
+	clock      clock.Clock
+	poolSize   int
+	saturation SaturationPolicy
+	// what to do with firing of job which is already running
+	concurrentExecution ConcurrentExecutionPolicy
+}
 
-	runningFutures sync.WaitGroup
-	lock           sync.Mutex
-	fMap           map[string]*future
+type defaultRuntimeExecutor struct {
+	executorOptions
+	store     stores.Store
+	registry  executorRegistry
+	listeners *listenerRegistry
+	pool      *workerPool
+	//nil if store does not support execution history
+	history stores.ExecutionStore
+
+	runningFutures  sync.WaitGroup
+	backgroundTasks sync.WaitGroup
+	lock            sync.Mutex
+	fMap            map[string]*future
+	//running executions by id
+	rMap map[string]*runningExecution
 
 	closeChan chan struct{}
+	//canceled on shutdown, aborts firings waiting for job lock, limits of job type or worker
+	waitCtx     context.Context
+	cancelWaits context.CancelFunc
+	//parent of all job contexts, canceled if shutdown context is done before running jobs finish
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
 }
 
 func (e *defaultRuntimeExecutor) CancelTriggers(keys ...string) int {
@@ -57,13 +99,116 @@ func (e *defaultRuntimeExecutor) CancelTriggers(keys ...string) int {
 	return canceled
 }
 
+type runningExecution struct {
+	ctx         *jobCtx
+	cancel      context.CancelFunc
+	interrupted *utils.AtomicBool
+}
+
+func (e *defaultRuntimeExecutor) InterruptJob(jKey string) int {
+	e.lock.Lock()
+	arr := make([]*runningExecution, 0)
+	for _, r := range e.rMap {
+		if r.ctx.job.Key() == jKey {
+			arr = append(arr, r)
+		}
+	}
+	e.lock.Unlock()
+
+	for _, r := range arr {
+		e.interrupt(r)
+	}
+
+	return len(arr)
+}
+
+func (e *defaultRuntimeExecutor) InterruptExecution(id string) bool {
+	e.lock.Lock()
+	r, ok := e.rMap[id]
+	e.lock.Unlock()
+
+	if ok {
+		e.interrupt(r)
+	}
+
+	return ok
+}
+
+func (e *defaultRuntimeExecutor) interrupt(r *runningExecution) {
+	if !r.interrupted.CompareAndSwap(false, true) {
+		return
+	}
+
+	log.Infof("defaultRuntimeExecutor: execution %v of job %v is interrupted", r.ctx.executionID, r.ctx.job.Key())
+	r.cancel()
+	e.listeners.jobWasInterrupted(r.ctx)
+}
+
+// interrupt executions requested by other instances
+func (e *defaultRuntimeExecutor) pollInterruptRequests() {
+	if e.history == nil {
+		return
+	}
+
+	ids, err := e.history.GetInterruptRequests(e.sName, e.instanceID)
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not get interrupt requests: %v", err)
+		return
+	}
+	for _, id := range ids {
+		e.InterruptExecution(id)
+	}
+}
+
+func (e *defaultRuntimeExecutor) CancelPendingTrigger(tKey string) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	f, ok := e.fMap[tKey]
+	if !ok {
+		return true
+	}
+	if f.IsRunning() {
+		return false
+	}
+
+	f.Cancel()
+	delete(e.fMap, tKey)
+	return true
+}
+
+func (e *defaultRuntimeExecutor) Stats() PoolStats {
+	return e.pool.Stats()
+}
+
 func (e *defaultRuntimeExecutor) Start() {
+	if e.clustered {
+		e.checkin()
+	}
+
+	//triggers acquired, jobs locked and executions started by previous run of this instance could not be running anymore
+	if _, err := e.store.ReleaseJobLocks(e.sName, e.instanceID); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not release job locks of previous run: %v", err)
+	}
+	e.abandonExecutions(e.instanceID, e.clock.Now())
+	released, err := e.store.ReleaseTriggers(e.sName, e.instanceID)
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not release triggers of previous run: %v", err)
+	} else {
+		e.handleRecovered(released)
+	}
+
+	e.recoverStaleTriggers()
 	e.startTriggerStealing()
 }
 
 func (e *defaultRuntimeExecutor) Shutdown(ctx context.Context) error {
 	//stop all internal background tasks
 	close(e.closeChan)
+	e.backgroundTasks.Wait()
+
+	//firings which have not started job yet return their triggers
+	e.cancelWaits()
 
 	//try release not running triggers
 	e.lock.Lock()
@@ -71,11 +216,9 @@ func (e *defaultRuntimeExecutor) Shutdown(ctx context.Context) error {
 		if !f.IsRunning() {
 			f.Cancel()
 			f.t = internal.ModifyTrigger(f.t, func(tr *internal.Trigger) {
-				tr.Tstate = triggers.StateScheduled //just release scheduled triggers
+				tr.Release(triggers.StateScheduled) //just release scheduled triggers
 			})
-			if err := e.store.UpdateTrigger(e.sName, f.t); err != nil {
-				log.Errorf("defaultRuntimeExecutor: could not update trigger %v: %v", f.t.Key(), err)
-			}
+			e.updateAcquired(f.t)
 			delete(e.fMap, key)
 		}
 	}
@@ -89,8 +232,9 @@ func (e *defaultRuntimeExecutor) Shutdown(ctx context.Context) error {
 	}()
 	select {
 	case <-ctx.Done():
+		//ask running jobs to stop
+		e.cancelJobs()
 	case <-awaitRunning:
-
 	}
 
 	//release remaining triggers
@@ -100,17 +244,47 @@ func (e *defaultRuntimeExecutor) Shutdown(ctx context.Context) error {
 	}*/
 	e.lock.Unlock()
 
+	if e.clustered {
+		if _, err := e.store.DeleteInstance(e.sName, e.instanceID); err != nil {
+			log.Errorf("defaultRuntimeExecutor: could not unregister instance %v: %v", e.instanceID, err)
+		}
+	}
+
 	return nil
 }
 
 func (e *defaultRuntimeExecutor) startTriggerStealing() {
+	e.backgroundTasks.Add(1)
 	go func() {
+		defer e.backgroundTasks.Done()
+
+		//dedicated ticker, so frequent recovery and checkin ticks could not postpone stealing
+		stealTicker := e.clock.NewTicker(e.timers.TriggerStealTimeout)
+		defer stealTicker.Stop()
+		recoveryTicker := e.clock.NewTicker(e.timers.TriggerRecoveryInterval)
+		defer recoveryTicker.Stop()
+
+		var checkinChan <-chan time.Time
+		if e.clustered {
+			checkinTicker := e.clock.NewTicker(e.timers.ClusterCheckinInterval)
+			defer checkinTicker.Stop()
+			checkinChan = checkinTicker.C()
+		}
+
 		for {
 			select {
 			case <-e.closeChan:
 				return
-			case <-time.After(e.timers.TriggerStealTimeout):
-				triggers, err := e.store.AcquireTriggers(e.sName)
+			case <-checkinChan:
+				e.checkin()
+				e.pollInterruptRequests()
+			case <-recoveryTicker.C():
+				//run in same goroutine as stealing to not race on released triggers
+				e.renewTriggers()
+				e.recoverStaleTriggers()
+				e.pruneExecutions()
+			case <-stealTicker.C():
+				triggers, err := e.acquireTriggers()
 
 				if err != nil {
 					log.Errorf("defaultRuntimeExecutor: could not acquire free triggers: %v", err)
@@ -120,6 +294,11 @@ func (e *defaultRuntimeExecutor) startTriggerStealing() {
 					if len(triggers) > 0 {
 						e.lock.Lock()
 						for _, t := range triggers {
+							//pending future of trigger released and acquired again must not fire it twice,
+							//running one finishes without touching new future
+							if old, ok := e.fMap[t.Key()]; ok && !old.IsRunning() {
+								old.Cancel()
+							}
 							e.fMap[t.Key()] = e.makeFuture(t)
 						}
 						e.lock.Unlock()
@@ -131,6 +310,205 @@ func (e *defaultRuntimeExecutor) startTriggerStealing() {
 	}()
 }
 
+func (e *defaultRuntimeExecutor) acquireTriggers() ([]triggers.ImmutableTrigger, error) {
+	now := e.clock.Now()
+	if !e.clustered {
+		return e.store.AcquireTriggers(e.sName, e.instanceID, now, time.Time{}, 0)
+	}
+
+	//acquire only near triggers by small batches to share them between instances
+	return e.store.AcquireTriggers(
+		e.sName,
+		e.instanceID,
+		now,
+		now.Add(e.timers.ClusterAcquireWindow),
+		e.timers.ClusterAcquireBatchSize,
+	)
+}
+
+// report heartbeat and take over triggers of dead instances
+func (e *defaultRuntimeExecutor) checkin() {
+	now := e.clock.Now()
+	if err := e.store.Heartbeat(e.sName, e.instanceID, now); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not report heartbeat: %v", err)
+		return
+	}
+
+	instances, err := e.store.GetInstances(e.sName)
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not get instances: %v", err)
+		return
+	}
+
+	for _, inst := range instances {
+		if inst.ID == e.instanceID || now.Sub(inst.LastHeartbeat) < e.timers.ClusterInstanceTimeout {
+			continue
+		}
+
+		log.Warnf("defaultRuntimeExecutor: instance %v is dead, last heartbeat: %s", inst.ID, inst.LastHeartbeat)
+		released, err := e.store.ReleaseTriggers(e.sName, inst.ID)
+		if err != nil {
+			log.Errorf("defaultRuntimeExecutor: could not release triggers of instance %v: %v", inst.ID, err)
+			continue
+		}
+		e.handleRecovered(released)
+
+		if _, err := e.store.ReleaseJobLocks(e.sName, inst.ID); err != nil {
+			log.Errorf("defaultRuntimeExecutor: could not release job locks of instance %v: %v", inst.ID, err)
+			continue
+		}
+
+		if !e.abandonExecutions(inst.ID, now) {
+			continue
+		}
+
+		if _, err := e.store.DeleteInstance(e.sName, inst.ID); err != nil {
+			log.Errorf("defaultRuntimeExecutor: could not unregister instance %v: %v", inst.ID, err)
+		}
+	}
+}
+
+// finish executions left running by dead instance, false if history could not be updated
+func (e *defaultRuntimeExecutor) abandonExecutions(instanceID string, now time.Time) bool {
+	if e.history == nil {
+		return true
+	}
+
+	n, err := e.history.AbandonExecutions(e.sName, instanceID, now)
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not abandon executions of instance %v: %v", instanceID, err)
+		return false
+	}
+	if n > 0 {
+		log.Warnf("defaultRuntimeExecutor: abandoned %d executions of instance %v", n, instanceID)
+	}
+
+	return true
+}
+
+func (e *defaultRuntimeExecutor) renewTriggers() {
+	e.lock.Lock()
+	keys := make([]string, 0, len(e.fMap))
+	for key := range e.fMap {
+		keys = append(keys, key)
+	}
+	e.lock.Unlock()
+
+	now := e.clock.Now()
+	if err := e.store.RenewTriggers(e.sName, e.instanceID, keys, now); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not renew acquired triggers: %v", err)
+	}
+	if err := e.store.RenewJobLocks(e.sName, e.instanceID, now); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not renew job locks: %v", err)
+	}
+}
+
+func (e *defaultRuntimeExecutor) recoverStaleTriggers() {
+	staleBefore := e.clock.Now().Add(-e.timers.TriggerRecoveryTimeout)
+
+	//job locks of dead instances would block their jobs forever
+	if n, err := e.store.ReleaseStaleJobLocks(e.sName, staleBefore); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not release stale job locks: %v", err)
+	} else if n > 0 {
+		log.Warnf("defaultRuntimeExecutor: released %d stale job locks", n)
+	}
+
+	released, err := e.store.ReleaseStaleTriggers(e.sName, staleBefore)
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not release stale triggers: %v", err)
+		return
+	}
+
+	e.handleRecovered(released)
+}
+
+func (e *defaultRuntimeExecutor) pruneExecutions() {
+	if e.history == nil || e.timers.ExecutionRetention <= 0 {
+		return
+	}
+
+	deleted, err := e.history.DeleteExecutions(e.sName, e.clock.Now().Add(-e.timers.ExecutionRetention))
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not delete old executions: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Debugf("defaultRuntimeExecutor: deleted %d old executions", deleted)
+	}
+}
+
+func (e *defaultRuntimeExecutor) handleRecovered(released []triggers.ImmutableTrigger) {
+	//missed fire times of recovered triggers are handled by misfire instruction on next acquiring
+	for _, t := range released {
+		log.Warnf("defaultRuntimeExecutor: trigger %v was recovered", t.Key())
+	}
+}
+
+func (e *defaultRuntimeExecutor) misfireInstruction(t triggers.ImmutableTrigger) triggers.MisfireInstruction {
+	if t.MisfireInstruction() != "" {
+		return t.MisfireInstruction()
+	}
+	return e.misfire
+}
+
+// load calendar referenced by trigger to exclude its times from next fire times
+// calendar referenced by trigger, false if it could not be resolved and trigger must not be fired
+func (e *defaultRuntimeExecutor) getCalendar(t triggers.ImmutableTrigger) (calendars.Calendar, bool) {
+	if t.CalendarName() == "" {
+		return nil, true
+	}
+
+	store, ok := e.store.(stores.CalendarStore)
+	if !ok {
+		log.Warnf("defaultRuntimeExecutor: calendar %v of trigger %v is ignored: %v", t.CalendarName(), t.Key(), calendars.ErrNotSupported)
+		return nil, true
+	}
+	cal, err := store.GetCalendar(e.sName, t.CalendarName())
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not get calendar %v of trigger %v: %v", t.CalendarName(), t.Key(), err)
+		return nil, false
+	}
+	if cal == nil {
+		log.Warnf("defaultRuntimeExecutor: calendar %v of trigger %v was not found", t.CalendarName(), t.Key())
+		return nil, false
+	}
+
+	return cal, true
+}
+
+func withCalendar(t triggers.ImmutableTrigger, cal calendars.Calendar) triggers.ImmutableTrigger {
+	return internal.ModifyTrigger(t, func(tr *internal.Trigger) {
+		tr.Tcal = cal
+	})
+}
+
+// reschedule misfired trigger to next fire time after now without firing
+func skipMisfired(
+	t triggers.ImmutableTrigger,
+	instruction triggers.MisfireInstruction,
+	now time.Time,
+) triggers.ImmutableTrigger {
+	return internal.ModifyTrigger(t, func(tr *internal.Trigger) {
+		if instruction == triggers.MisfireDoNothing {
+			for next := tr.TnextTime; !next.IsZero() && !next.After(now); next = internal.CalcNextTriggerTime(tr, next) {
+				tr.TtriggeredTime++
+				if tr.Trepeats != triggers.RepeatInfinity && tr.TtriggeredTime >= tr.Trepeats {
+					tr.Release(triggers.StateExhausted)
+					return
+				}
+			}
+		}
+
+		nextTime := internal.CalcNextTriggerTime(tr, now)
+		if nextTime.IsZero() {
+			tr.Release(triggers.StateExhausted)
+		} else {
+			tr.Release(triggers.StateScheduled)
+			tr.TnextTime = nextTime
+		}
+	})
+}
+
 func (e *defaultRuntimeExecutor) makeFuture(t triggers.ImmutableTrigger) *future {
 	future := &future{
 		t:        t,
@@ -140,29 +518,36 @@ func (e *defaultRuntimeExecutor) makeFuture(t triggers.ImmutableTrigger) *future
 	f := e.makeF(future)
 
 	var dur time.Duration
-	now := time.Now().In(t.Location())
+	now := e.clock.Now().In(t.Location())
 	if now.After(t.NextTriggerTime()) {
 		dur = 0
 	} else {
 		dur = t.NextTriggerTime().Sub(now)
 	}
 
-	future.timer = time.AfterFunc(dur, f)
+	future.timer = e.clock.AfterFunc(dur, f)
 
 	return future
 }
 
 func (e *defaultRuntimeExecutor) makeF(f *future) func() {
 	return func() {
+		//checked under lock, so future could not be canceled as pending one while it is starting
+		e.lock.Lock()
 		if f.IsCanceled() {
+			e.lock.Unlock()
 			return
 		}
-
 		f.Run()
+		e.lock.Unlock()
+
 		e.runningFutures.Add(1)
 		defer func() {
 			e.lock.Lock()
-			delete(e.fMap, f.t.Key())
+			//trigger could be already acquired again after release
+			if e.fMap[f.t.Key()] == f {
+				delete(e.fMap, f.t.Key())
+			}
 			e.lock.Unlock()
 
 			f.running.Set(false)
@@ -179,12 +564,44 @@ func (e *defaultRuntimeExecutor) makeF(f *future) func() {
 			log.Warnf("defaultRuntimeExecutor: trigger %v was deleted", f.t.Key())
 			return
 		}
-		now := time.Now().In(trigger.Location())
-		if !internal.IsNear(now, trigger.NextTriggerTime(), defaultDelta) {
-			log.Warnf("defaultRuntimeExecutor: trigger %v was updated. now: %s, next trigger time: %s", f.t.Key(), now, trigger.NextTriggerTime())
+		if trigger.State() == triggers.StatePaused {
+			log.Infof("defaultRuntimeExecutor: trigger %v was paused", f.t.Key())
+			return
+		}
+		if trigger.State() != triggers.StateAcquired || trigger.AcquiredBy() != e.instanceID {
+			log.Warnf("defaultRuntimeExecutor: trigger %v was taken over by instance %v", f.t.Key(), trigger.AcquiredBy())
+			return
+		}
+		now := e.clock.Now().In(trigger.Location())
+		fireTime := trigger.NextTriggerTime()
+		if fireTime.Sub(now) > e.timers.MisfireThreshold {
+			log.Warnf("defaultRuntimeExecutor: trigger %v was updated. now: %s, next trigger time: %s", f.t.Key(), now, fireTime)
+			e.putBack(releaseTrigger(trigger))
 			return
 		}
 
+		//excluded times are unknown without calendar, so trigger is left unfired until next acquisition
+		cal, ok := e.getCalendar(trigger)
+		if !ok {
+			e.putBack(releaseTrigger(trigger))
+			return
+		}
+		trigger = withCalendar(trigger, cal)
+
+		instruction := e.misfireInstruction(trigger)
+		if now.Sub(fireTime) > e.timers.MisfireThreshold {
+			log.Warnf(
+				"defaultRuntimeExecutor: trigger %v has misfired. now: %s, fire time: %s, instruction: %s",
+				trigger.Key(), now, fireTime, instruction,
+			)
+			e.listeners.triggerMisfired(trigger)
+
+			if instruction == triggers.MisfireSkip || instruction == triggers.MisfireDoNothing {
+				e.putBack(skipMisfired(trigger, instruction, now))
+				return
+			}
+		}
+
 		job, err := e.store.GetJob(e.sName, trigger.JobKey())
 		if err != nil {
 			log.Errorf("defaultRuntimeExecutor: could not get job %v: %v", trigger.JobKey(), err)
@@ -198,15 +615,63 @@ func (e *defaultRuntimeExecutor) makeF(f *future) func() {
 		exec, ok := e.registry.GetExecutor(job.Type())
 		if !ok {
 			log.Errorf("defaultRuntimeExecutor: not found executor for job type: %v", job.Type())
+			e.listeners.executorNotFound(job, trigger)
+			//executor could be registered later, so firing is retried after recovery interval
+			e.putBack(internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
+				tr.Release(triggers.StateScheduled)
+				tr.TnextTime = e.clock.Now().Add(e.timers.TriggerRecoveryInterval).In(tr.Tloc)
+			}))
+			return
+		}
+
+		//job lock and limits of job type are awaited before taking worker to not hold it idle
+		if job.ConcurrentExecutionDisallowed() {
+			if !e.lockJob(job, trigger) {
+				return
+			}
+			defer e.unlockJob(job)
+		}
+
+		if limiter := e.registry.GetLimiter(job.Type()); limiter != nil {
+			if !limiter.Acquire(e.waitCtx, e.clock) {
+				e.putBack(releaseTrigger(trigger))
+				return
+			}
+			defer limiter.Release()
+		}
+
+		if !e.acquireWorker(trigger) {
 			return
 		}
+		defer e.pool.Release()
+
+		execCtx, cancel := e.executionContext(job, trigger)
+		defer cancel()
 
 		ctx := &jobCtx{
-			job:     job,
-			trigger: trigger,
+			ctx:         execCtx,
+			job:         job,
+			trigger:     trigger,
+			executionID: newExecutionID(),
 		}
+		running := &runningExecution{
+			ctx:         ctx,
+			cancel:      cancel,
+			interrupted: utils.NewAtomicBool(false),
+		}
+		e.lock.Lock()
+		e.rMap[ctx.executionID] = running
+		e.lock.Unlock()
+		defer func() {
+			e.lock.Lock()
+			delete(e.rMap, ctx.executionID)
+			e.lock.Unlock()
+		}()
+		e.listeners.jobToBeExecuted(ctx)
+
+		startedAt := e.clock.Now()
+		execution := e.startExecution(ctx, startedAt)
 		doneChan := make(chan error, 1)
-		timeout := make(chan time.Time) //todo add timeout
 		go func() {
 			defer func() {
 				if err := recover(); err != nil {
@@ -216,51 +681,311 @@ func (e *defaultRuntimeExecutor) makeF(f *future) func() {
 			doneChan <- exec(ctx)
 		}()
 
+		returned := true
 		select {
-		case <-timeout:
-			err = ErrJobDeadlineExceeded
+		case <-execCtx.Done():
+			if execCtx.Err() == context.DeadlineExceeded {
+				err = ErrJobDeadlineExceeded
+			} else {
+				err = ErrJobCanceled
+			}
+			returned = false
 		case e := <-doneChan:
 			err = e
 		}
+		if err != nil && running.interrupted.Get() {
+			err = ErrJobInterrupted
+		}
 
 		if err != nil {
 			log.Warnf("defaultRuntimeExecutor: job %v has finished with err '%v' by trigger %v", job.Key(), err, trigger.Key())
 		}
 
+		finishedAt := e.clock.Now()
+		e.finishExecution(execution, ctx, finishedAt, err)
+		e.listeners.jobWasExecuted(ctx, ExecutionResult{
+			StartedAt: startedAt,
+			Duration:  finishedAt.Sub(startedAt),
+			Err:       err,
+		})
+
+		//job could ignore cancellation, so worker, limits of job type and job lock are held until it returns
+		if !returned {
+			<-doneChan
+		}
+
+		//trigger could be paused, rescheduled or deleted while job was running
+		if current, err := e.store.GetTrigger(e.sName, trigger.Key()); err != nil {
+			log.Errorf("defaultRuntimeExecutor: could not get trigger %v: %v", trigger.Key(), err)
+		} else if current == nil {
+			log.Warnf("defaultRuntimeExecutor: trigger %v was deleted", trigger.Key())
+			return
+		} else {
+			//calendar resolved for firing is kept if it could not be read again, so fired time is not repeated
+			if c, ok := e.getCalendar(current); ok {
+				cal = c
+			}
+			trigger = withCalendar(current, cal)
+		}
+		releaseState := triggers.StateScheduled
+		if trigger.State() == triggers.StatePaused {
+			releaseState = triggers.StatePaused
+		}
+
+		//firing canceled by shutdown is not counted as failed attempt, it is fired again by next owner of trigger
+		if err == ErrJobCanceled {
+			e.putBack(internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
+				tr.Release(releaseState)
+			}))
+			return
+		}
+
+		policy := trigger.RetryPolicy()
+		if policy == nil {
+			policy = job.RetryPolicy()
+		}
+		failedAttempts := trigger.FailedAttempts() + 1
+		//interrupted execution is not retried
+		if err != ErrJobInterrupted && policy.ShouldRetry(failedAttempts, err) {
+			delay := policy.Delay(failedAttempts)
+			log.Warnf(
+				"defaultRuntimeExecutor: job %v will be retried in %s, attempt %d of %d",
+				job.Key(), delay, failedAttempts+1, policy.MaxAttempts,
+			)
+			trigger = internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
+				tr.Release(releaseState)
+				tr.TfailedCount = failedAttempts
+				tr.TlastError = err.Error()
+				tr.TnextTime = e.clock.Now().Add(delay).In(tr.Tloc)
+			})
+			e.updateAcquired(trigger)
+			return
+		}
+
 		trigger = internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
+			tr.TfailedCount = 0
+			tr.TlastError = ""
 			tr.TtriggeredTime++
 			if tr.Trepeats != triggers.RepeatInfinity && tr.TtriggeredTime >= tr.Trepeats {
-				tr.Tstate = triggers.StateExhausted
+				tr.Release(triggers.StateExhausted)
 				return
 			}
 
-			nextTime := internal.CalcNextTriggerTime(tr)
+			//missed fire times are fired one by one
+			after := e.clock.Now()
+			if instruction == triggers.MisfireFireAll {
+				after = fireTime
+			}
+			nextTime := internal.CalcNextTriggerTime(tr, after)
 			if nextTime.IsZero() {
-				tr.Tstate = triggers.StateExhausted
+				tr.Release(triggers.StateExhausted)
 			} else {
-				tr.Tstate = triggers.StateScheduled
+				tr.Release(releaseState)
 				tr.TnextTime = nextTime
 			}
 		})
 
-		if err := e.store.UpdateTrigger(e.sName, trigger); err != nil {
-			log.Errorf("defaultRuntimeExecutor: could not update trigger %v: %v", trigger.Key(), err)
+		e.putBack(trigger)
+	}
+}
+
+// save running execution to history, nil if store does not support it
+func (e *defaultRuntimeExecutor) startExecution(ctx *jobCtx, startedAt time.Time) *executions.Execution {
+	if e.history == nil {
+		return nil
+	}
+
+	execution := &executions.Execution{
+		ID:         ctx.executionID,
+		JobKey:     ctx.job.Key(),
+		TriggerKey: ctx.trigger.Key(),
+		InstanceID: e.instanceID,
+		Attempt:    ctx.Attempt(),
+		StartedAt:  startedAt,
+		Status:     executions.StatusRunning,
+	}
+	if err := e.history.InsertExecution(e.sName, *execution); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not insert execution of job %v: %v", ctx.job.Key(), err)
+		return nil
+	}
+
+	return execution
+}
+
+func (e *defaultRuntimeExecutor) finishExecution(
+	execution *executions.Execution,
+	ctx *jobCtx,
+	finishedAt time.Time,
+	err error,
+) {
+	if execution == nil {
+		return
+	}
+
+	execution.FinishedAt = finishedAt
+	execution.Result = ctx.getResult()
+	if err == ErrJobInterrupted {
+		execution.Status = executions.StatusInterrupted
+		execution.Error = err.Error()
+	} else if err != nil {
+		execution.Status = executions.StatusFailed
+		execution.Error = err.Error()
+	} else {
+		execution.Status = executions.StatusSucceeded
+	}
+	if err := e.history.UpdateExecution(e.sName, *execution); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not update execution %v: %v", execution.ID, err)
+	}
+}
+
+// take worker according to saturation policy, trigger is returned to store if firing is not executed
+func (e *defaultRuntimeExecutor) acquireWorker(trigger triggers.ImmutableTrigger) bool {
+	switch e.saturation {
+	case SaturationDrop:
+		if e.pool.TryAcquire() {
+			return true
+		}
+		e.pool.Dropped()
+		log.Warnf("defaultRuntimeExecutor: worker pool is saturated, firing of trigger %v is dropped", trigger.Key())
+		e.listeners.triggerMisfired(trigger)
+		trigger = skipMisfired(trigger, triggers.MisfireSkip, e.clock.Now())
+	case SaturationDelay:
+		if e.pool.TryAcquire() {
+			return true
+		}
+		e.pool.Delayed()
+		log.Warnf("defaultRuntimeExecutor: worker pool is saturated, firing of trigger %v is delayed", trigger.Key())
+		trigger = releaseTrigger(trigger)
+	default:
+		if e.pool.Acquire(e.waitCtx) {
+			return true
+		}
+		//executor is shutting down
+		trigger = releaseTrigger(trigger)
+	}
+
+	e.putBack(trigger)
+	return false
+}
+
+// lock job if its concurrent execution is disallowed, trigger is returned to store if firing is not executed
+func (e *defaultRuntimeExecutor) lockJob(job jobs.ImmutableJob, trigger triggers.ImmutableTrigger) bool {
+	for {
+		ok, err := e.store.LockJob(e.sName, job.Key(), e.instanceID, e.clock.Now())
+		if err != nil {
+			log.Errorf("defaultRuntimeExecutor: could not lock job %v: %v", job.Key(), err)
+			e.putBack(releaseTrigger(trigger))
+			return false
+		}
+		if ok {
+			return true
 		}
+
+		if e.concurrentExecution == ConcurrentExecutionSkip {
+			log.Warnf("defaultRuntimeExecutor: job %v is already running, firing of trigger %v is skipped", job.Key(), trigger.Key())
+			e.listeners.triggerMisfired(trigger)
+			e.putBack(skipMisfired(trigger, triggers.MisfireSkip, e.clock.Now()))
+			return false
+		}
+
+		//job could be locked by other instance, so poll store
+		select {
+		case <-e.waitCtx.Done():
+			e.putBack(releaseTrigger(trigger))
+			return false
+		case <-e.clock.After(e.timers.TriggerStealTimeout):
+		}
+	}
+}
+
+func (e *defaultRuntimeExecutor) unlockJob(job jobs.ImmutableJob) {
+	if err := e.store.UnlockJob(e.sName, job.Key(), e.instanceID); err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not unlock job %v: %v", job.Key(), err)
+	}
+}
+
+// store trigger of firing which has not been executed
+func (e *defaultRuntimeExecutor) putBack(trigger triggers.ImmutableTrigger) {
+	if !e.updateAcquired(trigger) {
+		return
+	}
+	if trigger.State() == triggers.StateExhausted {
+		e.triggerExhausted(trigger)
+	}
+}
+
+// store trigger only if this instance still holds it, false if it has not been stored
+func (e *defaultRuntimeExecutor) updateAcquired(trigger triggers.ImmutableTrigger) bool {
+	err := e.store.UpdateAcquiredTrigger(e.sName, e.instanceID, trigger)
+	if err == stores.ErrTriggerLeaseLost {
+		log.Warnf("defaultRuntimeExecutor: trigger %v was released or taken over by other instance, its update is dropped", trigger.Key())
+		return false
+	}
+	if err != nil {
+		log.Errorf("defaultRuntimeExecutor: could not update trigger %v: %v", trigger.Key(), err)
+		return false
+	}
+	return true
+}
+
+func (e *defaultRuntimeExecutor) triggerExhausted(trigger triggers.ImmutableTrigger) {
+	e.listeners.triggerExhausted(trigger)
+
+	//manual triggers are not needed after firing, execution history keeps their runs
+	if IsManualTrigger(trigger) {
+		if _, err := e.store.DeleteTrigger(e.sName, trigger.Key()); err != nil {
+			log.Errorf("defaultRuntimeExecutor: could not delete trigger %v: %v", trigger.Key(), err)
+		}
+	}
+}
+
+func releaseTrigger(trigger triggers.ImmutableTrigger) triggers.ImmutableTrigger {
+	return internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
+		tr.Release(triggers.StateScheduled)
+	})
+}
+
+// trigger timeout overrides job timeout, job timeout overrides default one
+func (e *defaultRuntimeExecutor) executionContext(
+	job jobs.ImmutableJob,
+	trigger triggers.ImmutableTrigger,
+) (context.Context, context.CancelFunc) {
+	timeout := e.timers.JobTimeout
+	if job.Timeout() > 0 {
+		timeout = job.Timeout()
+	}
+	if trigger.Timeout() > 0 {
+		timeout = trigger.Timeout()
+	}
+
+	if timeout <= 0 {
+		return context.WithCancel(e.jobsCtx)
 	}
+	return clock.WithTimeout(e.jobsCtx, e.clock, timeout)
 }
 
 func newDefaultRuntimeExecutor(
-	sName string,
+	opts executorOptions,
 	store stores.Store,
 	registry executorRegistry,
-	timers Timers,
+	listeners *listenerRegistry,
 ) executor {
+	waitCtx, cancelWaits := context.WithCancel(context.Background())
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	history, _ := store.(stores.ExecutionStore)
 	return &defaultRuntimeExecutor{
-		sName:     sName,
-		store:     store,
-		registry:  registry,
-		timers:    timers,
-		closeChan: make(chan struct{}),
-		fMap:      make(map[string]*future),
+		executorOptions: opts,
+		store:           store,
+		registry:        registry,
+		listeners:       listeners,
+		pool:            newWorkerPool(opts.poolSize),
+		history:         history,
+		closeChan:       make(chan struct{}),
+		fMap:            make(map[string]*future),
+		rMap:            make(map[string]*runningExecution),
+		waitCtx:         waitCtx,
+		cancelWaits:     cancelWaits,
+		jobsCtx:         jobsCtx,
+		cancelJobs:      cancelJobs,
 	}
 }
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	"math"
+	"sync"
+	"time"
+)
+
+type ExecutorOption func(l *executionLimiter)
+
+// limit count of concurrently executed jobs of same type
+func WithMaxConcurrency(n int) ExecutorOption {
+	return func(l *executionLimiter) {
+		l.maxConcurrent = n
+	}
+}
+
+// token bucket limit, perSecond tokens are added each second up to burst
+func WithRateLimit(perSecond float64, burst int) ExecutorOption {
+	return func(l *executionLimiter) {
+		l.rate = perSecond
+		l.burst = float64(burst)
+		if l.burst < 1 {
+			l.burst = 1
+		}
+		l.tokens = l.burst
+	}
+}
+
+// minimal duration between starts of two executions of same type
+func WithMinInterval(d time.Duration) ExecutorOption {
+	return func(l *executionLimiter) {
+		l.minInterval = d
+	}
+}
+
+type executionLimiter struct {
+	maxConcurrent int
+	rate          float64
+	burst         float64
+	minInterval   time.Duration
+
+	lock       sync.Mutex
+	running    int
+	tokens     float64
+	lastRefill time.Time
+	lastStart  time.Time
+	// closed when running execution is finished
+	released chan struct{}
+}
+
+// wait until execution is allowed by all limits, return false if ctx is done before
+func (l *executionLimiter) Acquire(ctx context.Context, c clock.Clock) bool {
+	for {
+		l.lock.Lock()
+		wait, released := l.reserve(c.Now())
+		l.lock.Unlock()
+
+		if wait == 0 && released == nil {
+			return true
+		}
+
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = c.After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer:
+		case <-released:
+		}
+	}
+}
+
+func (l *executionLimiter) Release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.running--
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// reserve execution or return how long to wait
+func (l *executionLimiter) reserve(now time.Time) (time.Duration, <-chan struct{}) {
+	if l.maxConcurrent > 0 && l.running >= l.maxConcurrent {
+		return 0, l.released
+	}
+
+	var wait time.Duration
+	if l.rate > 0 {
+		if !l.lastRefill.IsZero() {
+			l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.lastRefill).Seconds()*l.rate)
+		}
+		l.lastRefill = now
+		if l.tokens < 1 {
+			wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+	}
+	if l.minInterval > 0 && !l.lastStart.IsZero() {
+		if d := l.lastStart.Add(l.minInterval).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return wait, nil
+	}
+
+	l.running++
+	if l.rate > 0 {
+		l.tokens--
+	}
+	l.lastStart = now
+	return 0, nil
+}
+
+func newExecutionLimiter(opts []ExecutorOption) *executionLimiter {
+	if len(opts) == 0 {
+		return nil
+	}
+
+	l := &executionLimiter{released: make(chan struct{})}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestExecutionLimiter(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	acquireAsync := func(l *executionLimiter, c clock.Clock) chan bool {
+		ch := make(chan bool, 1)
+		go func() {
+			ch <- l.Acquire(context.Background(), c)
+		}()
+		return ch
+	}
+
+	Convey("Concurrent executions must be limited", t, func() {
+		fake := clock.NewFake(start)
+		l := newExecutionLimiter([]ExecutorOption{WithMaxConcurrency(1)})
+		So(l.Acquire(context.Background(), fake), ShouldBeTrue)
+
+		ch := acquireAsync(l, fake)
+		select {
+		case <-ch:
+			So("second execution must wait", ShouldBeEmpty)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		l.Release()
+		So(<-ch, ShouldBeTrue)
+	})
+
+	Convey("Executions must be rate limited by token bucket", t, func() {
+		fake := clock.NewFake(start)
+		l := newExecutionLimiter([]ExecutorOption{WithRateLimit(2, 2)})
+		So(l.Acquire(context.Background(), fake), ShouldBeTrue)
+		l.Release()
+		So(l.Acquire(context.Background(), fake), ShouldBeTrue)
+		l.Release()
+
+		ch := acquireAsync(l, fake)
+		fake.BlockUntil(1)
+		fake.Advance(500 * time.Millisecond)
+		So(<-ch, ShouldBeTrue)
+	})
+
+	Convey("Executions must be spread by min interval", t, func() {
+		fake := clock.NewFake(start)
+		l := newExecutionLimiter([]ExecutorOption{WithMinInterval(time.Minute)})
+		So(l.Acquire(context.Background(), fake), ShouldBeTrue)
+		l.Release()
+
+		ch := acquireAsync(l, fake)
+		fake.BlockUntil(1)
+		fake.Advance(30 * time.Second)
+		fake.BlockUntil(1)
+		fake.Advance(30 * time.Second)
+		So(<-ch, ShouldBeTrue)
+	})
+
+	Convey("Waiting must be aborted when context is done", t, func() {
+		l := newExecutionLimiter([]ExecutorOption{WithMaxConcurrency(1)})
+		So(l.Acquire(context.Background(), clock.New()), ShouldBeTrue)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		So(l.Acquire(ctx, clock.New()), ShouldBeFalse)
+	})
+}
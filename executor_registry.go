@@ -5,27 +5,37 @@ import "sync"
 type JobExecutor func(ctx JobContext) error
 
 type executorRegistry interface {
-	Register(jType string, executor JobExecutor)
+	Register(jType string, executor JobExecutor, opts ...ExecutorOption)
 	RegisterAll(map[string]JobExecutor)
 	Unregister(jType string)
 	GetExecutor(jType string) (JobExecutor, bool)
+	// nil if executor has no limits
+	GetLimiter(jType string) *executionLimiter
+}
+
+type registeredExecutor struct {
+	executor JobExecutor
+	limiter  *executionLimiter
 }
 
 type defaultExecutorRegistry struct {
 	lock     sync.RWMutex
-	registry map[string]JobExecutor
+	registry map[string]registeredExecutor
 }
 
-func (r *defaultExecutorRegistry) Register(jType string, executor JobExecutor) {
+func (r *defaultExecutorRegistry) Register(jType string, executor JobExecutor, opts ...ExecutorOption) {
 	r.lock.Lock()
-	r.registry[jType] = executor
+	r.registry[jType] = registeredExecutor{
+		executor: executor,
+		limiter:  newExecutionLimiter(opts),
+	}
 	r.lock.Unlock()
 }
 
 func (r *defaultExecutorRegistry) RegisterAll(m map[string]JobExecutor) {
 	r.lock.Lock()
 	for t, e := range m {
-		r.registry[t] = e
+		r.registry[t] = registeredExecutor{executor: e}
 	}
 	r.lock.Unlock()
 }
@@ -40,11 +50,18 @@ func (r *defaultExecutorRegistry) GetExecutor(jType string) (JobExecutor, bool)
 	r.lock.RLock()
 	e, ok := r.registry[jType]
 	r.lock.RUnlock()
-	return e, ok
+	return e.executor, ok
+}
+
+func (r *defaultExecutorRegistry) GetLimiter(jType string) *executionLimiter {
+	r.lock.RLock()
+	e := r.registry[jType]
+	r.lock.RUnlock()
+	return e.limiter
 }
 
 func newDefaultExecutorRegistry() executorRegistry {
 	return &defaultExecutorRegistry{
-		registry: make(map[string]JobExecutor),
+		registry: make(map[string]registeredExecutor),
 	}
 }
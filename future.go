@@ -1,13 +1,13 @@
 package scheduler
 
 import (
+	"github.com/d1slike/go-sched/clock"
 	"github.com/d1slike/go-sched/triggers"
 	"github.com/d1slike/go-sched/utils"
-	"time"
 )
 
 type future struct {
-	timer    *time.Timer
+	timer    clock.Timer
 	t        triggers.ImmutableTrigger
 	running  *utils.AtomicBool
 	canceled *utils.AtomicBool
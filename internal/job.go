@@ -3,12 +3,22 @@ package internal
 import (
 	"github.com/d1slike/go-sched/jobs"
 	"github.com/d1slike/go-sched/log"
+	"github.com/d1slike/go-sched/retry"
+	"time"
 )
 
 type Job struct {
-	Jkey   string
-	JjType string
-	Jdata  []byte
+	Jkey     string
+	JjType   string
+	Jdata    []byte
+	Jtimeout time.Duration
+	Jretry   *retry.Policy
+	// disallow concurrent execution
+	Jexclusive bool
+	// keep job without triggers
+	Jdurable bool
+	// delete job with its last trigger
+	JdeleteOrphaned bool
 }
 
 func (j *Job) ToImmutable() (jobs.ImmutableJob, error) {
@@ -33,8 +43,28 @@ func (j *Job) Data() []byte {
 	return j.Jdata
 }
 
+func (j *Job) Timeout() time.Duration {
+	return j.Jtimeout
+}
+
+func (j *Job) RetryPolicy() *retry.Policy {
+	return j.Jretry
+}
+
+func (j *Job) ConcurrentExecutionDisallowed() bool {
+	return j.Jexclusive
+}
+
+func (j *Job) Durable() bool {
+	return j.Jdurable
+}
+
+func (j *Job) DeletedWithLastTrigger() bool {
+	return j.JdeleteOrphaned
+}
+
 func (j *Job) WithData(data interface{}) jobs.MutableJob {
-	if b, err := castData(data); err != nil {
+	if b, err := CastData(data); err != nil {
 		log.Errorf("job key: %s, jon type: %s : %v", j.Jkey, j.JjType, err)
 	} else {
 		j.Jdata = b
@@ -52,6 +82,31 @@ func (j *Job) WithType(jType string) jobs.MutableJob {
 	return j
 }
 
+func (j *Job) WithTimeout(timeout time.Duration) jobs.MutableJob {
+	j.Jtimeout = timeout
+	return j
+}
+
+func (j *Job) WithRetryPolicy(policy retry.Policy) jobs.MutableJob {
+	j.Jretry = &policy
+	return j
+}
+
+func (j *Job) DisallowConcurrentExecution() jobs.MutableJob {
+	j.Jexclusive = true
+	return j
+}
+
+func (j *Job) StoreDurably() jobs.MutableJob {
+	j.Jdurable = true
+	return j
+}
+
+func (j *Job) DeleteWithLastTrigger() jobs.MutableJob {
+	j.JdeleteOrphaned = true
+	return j
+}
+
 func NewJob() *Job {
 	return &Job{}
 }
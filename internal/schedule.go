@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"fmt"
+	"github.com/d1slike/go-sched/triggers"
+	"github.com/d1slike/go-sched/utils"
+	"strings"
+	"time"
+)
+
+// max wall clock times of schedule checked by single wallClockSchedule.Next call
+const maxWallClockCandidates = 100000
+
+// calculates fire times of trigger, implemented by parsed cron spec too
+type Schedule interface {
+	// first fire time strictly after given time, zero if never fire
+	Next(after time.Time) time.Time
+}
+
+// fires every interval starting exactly at anchor
+type intervalSchedule struct {
+	anchor   time.Time
+	interval time.Duration
+}
+
+func (s intervalSchedule) Next(after time.Time) time.Time {
+	if after.Before(s.anchor) {
+		return s.anchor.In(after.Location())
+	}
+	passed := after.Sub(s.anchor) / s.interval
+	return s.anchor.Add((passed + 1) * s.interval).In(after.Location())
+}
+
+// fires once at given time
+type onceSchedule struct {
+	at time.Time
+}
+
+func (s onceSchedule) Next(after time.Time) time.Time {
+	if after.Before(s.at) {
+		return s.at.In(after.Location())
+	}
+	return time.Time{}
+}
+
+// fires every interval from start to end time of day inclusive on given weekdays.
+// works with floating wall clock times, see wallClockSchedule
+type dailyIntervalSchedule struct {
+	start    time.Duration
+	end      time.Duration
+	interval time.Duration
+	days     [7]bool
+}
+
+func newDailyIntervalSchedule(
+	start, end string,
+	interval time.Duration,
+	days []time.Weekday,
+) (*dailyIntervalSchedule, error) {
+	s := &dailyIntervalSchedule{interval: interval}
+	var err error
+	if s.start, err = parseTimeOfDay(start); err != nil {
+		return nil, err
+	}
+	if s.end, err = parseTimeOfDay(end); err != nil {
+		return nil, err
+	}
+	if s.end < s.start {
+		return nil, triggers.ErrInvalidTimeWindow
+	}
+	if interval <= 0 {
+		return nil, triggers.ErrInvalidInterval
+	}
+
+	for _, d := range days {
+		s.days[d] = true
+	}
+	//every day if days are not given
+	if len(days) == 0 {
+		s.days = [7]bool{true, true, true, true, true, true, true}
+	}
+
+	return s, nil
+}
+
+func (s *dailyIntervalSchedule) Next(after time.Time) time.Time {
+	y, m, d := after.Date()
+	tod := time.Duration(after.Hour())*time.Hour + time.Duration(after.Minute())*time.Minute +
+		time.Duration(after.Second())*time.Second + time.Duration(after.Nanosecond())
+
+	//window of next week day is the latest one which could contain next time
+	for i := 0; i <= 7; i++ {
+		if !s.days[time.Date(y, m, d+i, 12, 0, 0, 0, time.UTC).Weekday()] {
+			continue
+		}
+
+		offset := s.start
+		if i == 0 && tod >= s.start {
+			offset += ((tod-s.start)/s.interval + 1) * s.interval
+		}
+		if offset <= s.end {
+			return time.Date(y, m, d+i, 0, 0, 0, int(offset), after.Location())
+		}
+	}
+
+	return time.Time{}
+}
+
+// resolves wall clock times of schedule in location by DST policy
+type wallClockSchedule struct {
+	// schedule of floating wall clock times, see utils.Floating
+	wall    Schedule
+	loc     *time.Location
+	gap     triggers.DSTGapPolicy
+	overlap triggers.DSTOverlapPolicy
+}
+
+func (s *wallClockSchedule) Next(after time.Time) time.Time {
+	w := utils.Floating(after.In(s.loc))
+	//wall clock times before given one could be shifted after it by DST transition
+	if offBefore, offAfter := utils.NearOffsets(w, s.loc); offBefore != offAfter {
+		w = w.Add(-utils.MaxDuration(offBefore-offAfter, offAfter-offBefore))
+	}
+
+	var next time.Time
+	for i := 0; i < maxWallClockCandidates; i++ {
+		if w = s.wall.Next(w); w.IsZero() {
+			break
+		}
+		//instants of this and later wall clock times are not earlier than this bound
+		bound := w.Add(-utils.MaxDuration(utils.NearOffsets(w, s.loc)))
+		if !next.IsZero() && !bound.Before(next) {
+			break
+		}
+
+		instants, gap := utils.ResolveWallClock(w, s.loc)
+		switch {
+		case gap && s.gap == triggers.DSTGapSkip:
+			instants = nil
+		case len(instants) > 1 && s.overlap != triggers.DSTOverlapTwice:
+			instants = instants[:1]
+		}
+		for _, t := range instants {
+			if t.After(after) && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+
+	if next.IsZero() {
+		return next
+	}
+	return next.In(after.Location())
+}
+
+// parse time of day given as "15:04" or "15:04:05" to offset from midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	layout := "15:04:05"
+	if strings.Count(s, ":") == 1 {
+		layout = "15:04"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return 0, fmt.Errorf(triggers.ErrInvalidTimeOfDay, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+}
@@ -2,27 +2,56 @@ package internal
 
 import (
 	"fmt"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/cronexpr"
 	"github.com/d1slike/go-sched/log"
+	"github.com/d1slike/go-sched/retry"
+	"github.com/d1slike/go-sched/rrule"
 	"github.com/d1slike/go-sched/triggers"
 	"github.com/robfig/cron"
 	"time"
 )
 
+// max fire times excluded by calendar which are skipped looking for next time, trigger never fires after them
+const maxCalendarSkips = 1000
+
 type Trigger struct {
 	Tkey      string
 	TjobKey   string
 	TfromTime *time.Time
 	TtoTime   *time.Time
 	Trepeats  triggers.Repeats
+	Tkind     triggers.ScheduleKind
 	TcronSpec string
+	Tinterval time.Duration
+	Trrule    string
+	TdayStart string
+	TdayEnd   string
+	Tdays     []time.Weekday
+	Tgap      triggers.DSTGapPolicy
+	Toverlap  triggers.DSTOverlapPolicy
+	Tcalendar string
 	Tlocation string
 	Tdata     []byte
+	Ttimeout  time.Duration
+	Tretry    *retry.Policy
+	Tmisfire  triggers.MisfireInstruction
 
 	Tstate         triggers.TriggerState
 	Tloc           *time.Location
 	TtriggeredTime triggers.Repeats
-	Tsched         cron.Schedule
-	TnextTime      time.Time
+	Tsched         Schedule
+	//calendar referenced by Tcalendar, resolved by scheduler before calculating next time
+	Tcal calendars.Calendar
+	//delay of one-shot trigger, resolved to from time on scheduling
+	Tdelay       time.Duration
+	TnextTime    time.Time
+	TinstanceID  string
+	TacquiredAt  time.Time
+	TfailedCount int
+	TlastError   string
+	//created by Scheduler.TriggerJob
+	Tmanual bool
 }
 
 func (t *Trigger) Data() []byte {
@@ -41,6 +70,14 @@ func (t *Trigger) JobKey() string {
 	return t.TjobKey
 }
 
+func (t *Trigger) AcquiredBy() string {
+	return t.TinstanceID
+}
+
+func (t *Trigger) AcquiredAt() time.Time {
+	return t.TacquiredAt
+}
+
 func (t *Trigger) WithKey(tKey string) triggers.MutableTrigger {
 	t.Tkey = tKey
 	return t
@@ -62,12 +99,69 @@ func (t *Trigger) WithRepeats(repeats triggers.Repeats) triggers.MutableTrigger
 }
 
 func (t *Trigger) WithCron(spec string) triggers.MutableTrigger {
+	t.Tkind = triggers.KindCron
 	t.TcronSpec = spec
 	return t
 }
 
+func (t *Trigger) WithExtendedCron(spec string) triggers.MutableTrigger {
+	t.Tkind = triggers.KindExtendedCron
+	t.TcronSpec = spec
+	return t
+}
+
+func (t *Trigger) WithInterval(interval time.Duration) triggers.MutableTrigger {
+	t.Tkind = triggers.KindInterval
+	t.Tinterval = interval
+	return t
+}
+
+func (t *Trigger) WithRRule(spec string) triggers.MutableTrigger {
+	t.Tkind = triggers.KindRRule
+	t.Trrule = spec
+	return t
+}
+
+func (t *Trigger) WithDailyInterval(
+	start, end string,
+	interval time.Duration,
+	days ...time.Weekday,
+) triggers.MutableTrigger {
+	t.Tkind = triggers.KindDailyInterval
+	t.TdayStart = start
+	t.TdayEnd = end
+	t.Tinterval = interval
+	t.Tdays = append([]time.Weekday(nil), days...)
+	return t
+}
+
+func (t *Trigger) WithDSTPolicy(gap triggers.DSTGapPolicy, overlap triggers.DSTOverlapPolicy) triggers.MutableTrigger {
+	t.Tgap = gap
+	t.Toverlap = overlap
+	return t
+}
+
+func (t *Trigger) At(fireTime time.Time) triggers.MutableTrigger {
+	t.Tkind = triggers.KindOnce
+	t.TfromTime = &fireTime
+	t.Tdelay = 0
+	return t
+}
+
+func (t *Trigger) After(delay time.Duration) triggers.MutableTrigger {
+	t.Tkind = triggers.KindOnce
+	t.TfromTime = nil
+	t.Tdelay = delay
+	return t
+}
+
+func (t *Trigger) WithCalendar(name string) triggers.MutableTrigger {
+	t.Tcalendar = name
+	return t
+}
+
 func (t *Trigger) WithData(data interface{}) triggers.MutableTrigger {
-	if b, err := castData(data); err != nil {
+	if b, err := CastData(data); err != nil {
 		log.Errorf("trigger key: %v", t.Tkey, err)
 	} else {
 		t.Tdata = b
@@ -80,27 +174,84 @@ func (t *Trigger) InLocation(loc string) triggers.MutableTrigger {
 	return t
 }
 
+func (t *Trigger) WithTimeout(timeout time.Duration) triggers.MutableTrigger {
+	t.Ttimeout = timeout
+	return t
+}
+
+func (t *Trigger) WithRetryPolicy(policy retry.Policy) triggers.MutableTrigger {
+	t.Tretry = &policy
+	return t
+}
+
+func (t *Trigger) WithMisfireInstruction(instruction triggers.MisfireInstruction) triggers.MutableTrigger {
+	t.Tmisfire = instruction
+	return t
+}
+
 func (t *Trigger) ToImmutable() (triggers.ImmutableTrigger, error) {
+	return t.ToImmutableAt(time.Now())
+}
+
+// schedules relative to scheduling time are anchored to now.
+// builder could be reused for other triggers, so its copy is returned
+func (t *Trigger) ToImmutableAt(now time.Time) (triggers.ImmutableTrigger, error) {
 	if t.Tkey == "" {
 		return nil, triggers.ErrEmptyTriggerKey
 	}
-	if t.TcronSpec == "" {
-		return nil, triggers.ErrEmptyCronSpec
+	cpy := *t
+	t = &cpy
+	switch t.Kind() {
+	case triggers.KindCron, triggers.KindExtendedCron:
+		if t.TcronSpec == "" {
+			return nil, triggers.ErrEmptyCronSpec
+		}
+	case triggers.KindInterval:
+		if t.Tinterval <= 0 {
+			return nil, triggers.ErrInvalidInterval
+		}
+		if t.TfromTime == nil {
+			t.TfromTime = &now
+		}
+	case triggers.KindRRule:
+		if t.Trrule == "" {
+			return nil, triggers.ErrEmptyRRule
+		}
+		if t.TfromTime == nil {
+			dtstart := now.Truncate(time.Second)
+			t.TfromTime = &dtstart
+		}
+	case triggers.KindDailyInterval:
+		if t.Tinterval <= 0 {
+			return nil, triggers.ErrInvalidInterval
+		}
+	case triggers.KindOnce:
+		if t.Tdelay > 0 {
+			at := now.Add(t.Tdelay)
+			t.TfromTime = &at
+			t.Tdelay = 0
+		}
+		if t.TfromTime == nil {
+			return nil, triggers.ErrEmptyFireTime
+		}
+	default:
+		return nil, fmt.Errorf(triggers.ErrInvalidScheduleKind, t.Tkind)
 	}
-
-	loc, err := time.LoadLocation(t.Tlocation)
-	if err != nil {
-		return nil, fmt.Errorf(triggers.ErrInvalidLocation, err)
+	if !t.Tmisfire.IsValid() {
+		return nil, fmt.Errorf(triggers.ErrInvalidMisfire, t.Tmisfire)
+	}
+	if !t.Tgap.IsValid() {
+		return nil, fmt.Errorf(triggers.ErrInvalidDSTPolicy, t.Tgap)
+	}
+	if !t.Toverlap.IsValid() {
+		return nil, fmt.Errorf(triggers.ErrInvalidDSTPolicy, t.Toverlap)
 	}
-	t.Tloc = loc
 
-	sched, err := cron.Parse(t.TcronSpec)
-	if err != nil {
-		return nil, fmt.Errorf(triggers.ErrInvalidCronSpec, err)
+	if err := t.Restore(); err != nil {
+		return nil, err
 	}
-	t.Tsched = sched
 
-	nextTime := CalcNextTriggerTime(t)
+	nextTime := CalcNextTriggerTime(t, now)
 	if nextTime.IsZero() {
 		return nil, triggers.ErrAlreadyExhausted
 	} else {
@@ -110,6 +261,72 @@ func (t *Trigger) ToImmutable() (triggers.ImmutableTrigger, error) {
 	return t, nil
 }
 
+// resolve location and schedule from persisted fields
+func (t *Trigger) Restore() error {
+	loc, err := time.LoadLocation(t.Tlocation)
+	if err != nil {
+		return fmt.Errorf(triggers.ErrInvalidLocation, err)
+	}
+	t.Tloc = loc
+
+	switch t.Kind() {
+	case triggers.KindInterval:
+		if t.TfromTime != nil && t.Tinterval > 0 {
+			t.Tsched = intervalSchedule{anchor: *t.TfromTime, interval: t.Tinterval}
+		}
+	case triggers.KindOnce:
+		if t.TfromTime != nil {
+			t.Tsched = onceSchedule{at: *t.TfromTime}
+		}
+	case triggers.KindDailyInterval:
+		sched, err := newDailyIntervalSchedule(t.TdayStart, t.TdayEnd, t.Tinterval, t.Tdays)
+		if err != nil {
+			return err
+		}
+		t.Tsched = t.wallClock(sched)
+	case triggers.KindExtendedCron:
+		if t.TcronSpec == "" {
+			return nil
+		}
+		expr, err := cronexpr.Parse(t.TcronSpec)
+		if err != nil {
+			return fmt.Errorf(triggers.ErrInvalidCronSpec, err)
+		}
+		t.Tsched = t.wallClock(expr)
+	case triggers.KindRRule:
+		if t.Trrule == "" || t.TfromTime == nil {
+			return nil
+		}
+		set, err := rrule.Parse(t.Trrule)
+		if err != nil {
+			return fmt.Errorf(triggers.ErrInvalidRRule, err)
+		}
+		//wall clock parts of occurrences are taken in trigger location
+		t.Tsched = set.Schedule(t.TfromTime.In(t.Tloc))
+	default:
+		if t.TcronSpec == "" {
+			return nil
+		}
+		sched, err := cron.Parse(t.TcronSpec)
+		if err != nil {
+			return fmt.Errorf(triggers.ErrInvalidCronSpec, err)
+		}
+		//"@every" spec fires by elapsed time
+		if _, ok := sched.(cron.ConstantDelaySchedule); ok {
+			t.Tsched = sched
+		} else {
+			t.Tsched = t.wallClock(sched)
+		}
+	}
+
+	return nil
+}
+
+// resolve wall clock times of schedule in trigger location by its DST policy
+func (t *Trigger) wallClock(sched Schedule) Schedule {
+	return &wallClockSchedule{wall: sched, loc: t.Tloc, gap: t.Tgap, overlap: t.Toverlap}
+}
+
 func (t *Trigger) Key() string {
 	return t.Tkey
 }
@@ -126,18 +343,89 @@ func (t *Trigger) Repeats() triggers.Repeats {
 	return t.Trepeats
 }
 
+// trigger without kind is cron one
+func (t *Trigger) Kind() triggers.ScheduleKind {
+	if t.Tkind == "" {
+		return triggers.KindCron
+	}
+	return t.Tkind
+}
+
 func (t *Trigger) CronSpec() string {
 	return t.TcronSpec
 }
 
+func (t *Trigger) Interval() time.Duration {
+	return t.Tinterval
+}
+
+func (t *Trigger) RRule() string {
+	return t.Trrule
+}
+
+func (t *Trigger) DailyWindow() (string, string) {
+	return t.TdayStart, t.TdayEnd
+}
+
+func (t *Trigger) DaysOfWeek() []time.Weekday {
+	return t.Tdays
+}
+
+func (t *Trigger) DSTPolicy() (triggers.DSTGapPolicy, triggers.DSTOverlapPolicy) {
+	return t.Tgap, t.Toverlap
+}
+
+func (t *Trigger) CalendarName() string {
+	return t.Tcalendar
+}
+
 func (t *Trigger) Location() *time.Location {
 	return t.Tloc
 }
 
+func (t *Trigger) Timeout() time.Duration {
+	return t.Ttimeout
+}
+
+func (t *Trigger) RetryPolicy() *retry.Policy {
+	return t.Tretry
+}
+
+func (t *Trigger) MisfireInstruction() triggers.MisfireInstruction {
+	return t.Tmisfire
+}
+
+func (t *Trigger) FailedAttempts() int {
+	return t.TfailedCount
+}
+
+func (t *Trigger) LastError() string {
+	return t.TlastError
+}
+
+func (t *Trigger) Manual() bool {
+	return t.Tmanual
+}
+
 func (t *Trigger) NextTriggerTime() time.Time {
 	return t.TnextTime
 }
 
+// set trigger state and drop acquisition ownership
+func (t *Trigger) Release(state triggers.TriggerState) {
+	t.Tstate = state
+	t.TinstanceID = ""
+	t.TacquiredAt = time.Time{}
+}
+
+// convert trigger anchoring relative schedules to now, see Trigger.ToImmutableAt
+func ToImmutableAt(t triggers.MutableTrigger, now time.Time) (triggers.ImmutableTrigger, error) {
+	if trigger, ok := t.(*Trigger); ok {
+		return trigger.ToImmutableAt(now)
+	}
+	return t.ToImmutable()
+}
+
 func ModifyTrigger(t triggers.ImmutableTrigger, f func(tr *Trigger)) triggers.ImmutableTrigger {
 	if trigger, ok := t.(*Trigger); ok {
 		cpy := *trigger
@@ -154,31 +442,30 @@ func NewTrigger() *Trigger {
 	}
 }
 
-// calc next trigger time considering fromTime, toTime boundary
+// calc next trigger time after given time considering fromTime (inclusive), toTime boundary and calendar
 // return zero time if never fire
-func CalcNextTriggerTime(t *Trigger) time.Time {
-	var nextTime time.Time
-	if t.TfromTime != nil {
-		nextTime = t.Tsched.Next(t.TfromTime.In(t.Tloc))
-	} else {
-		nextTime = t.Tsched.Next(time.Now().In(t.Tloc))
-	}
-
-	if nextTime.IsZero() || (t.TtoTime != nil && t.TtoTime.Before(nextTime)) {
+func CalcNextTriggerTime(t *Trigger, after time.Time) time.Time {
+	if t.Tsched == nil {
 		return time.Time{}
 	}
 
-	return nextTime
-}
-
-func IsNear(a, b time.Time, delta time.Duration) bool {
-	if a.Equal(b) {
-		return true
+	from := after.In(t.Tloc)
+	if t.TfromTime != nil && t.TfromTime.After(from) {
+		from = t.TfromTime.Add(-time.Nanosecond).In(t.Tloc)
+	}
+	nextTime := t.Tsched.Next(from)
+	//fire times excluded by calendar are skipped
+	for i := 0; t.Tcal != nil && !nextTime.IsZero() && !t.Tcal.IsIncluded(nextTime); i++ {
+		included := t.Tcal.NextIncluded(nextTime)
+		if included.IsZero() || i == maxCalendarSkips {
+			return time.Time{}
+		}
+		nextTime = t.Tsched.Next(included.Add(-time.Nanosecond).In(t.Tloc))
 	}
 
-	if a.After(b) {
-		return a.Sub(b) <= delta
+	if nextTime.IsZero() || (t.TtoTime != nil && t.TtoTime.Before(nextTime)) {
+		return time.Time{}
 	}
 
-	return b.Sub(a) <= delta
+	return nextTime
 }
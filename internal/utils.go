@@ -4,7 +4,7 @@ import (
 	"github.com/d1slike/go-sched/json"
 )
 
-func castData(data interface{}) ([]byte, error) {
+func CastData(data interface{}) ([]byte, error) {
 	switch d := data.(type) {
 	case []byte:
 		return d, nil
@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/executions"
+	"github.com/d1slike/go-sched/stores"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+	"time"
+)
+
+type interruptListener struct {
+	NopJobListener
+
+	lock        sync.Mutex
+	interrupted []string
+	results     []ExecutionResult
+}
+
+func (l *interruptListener) JobWasInterrupted(ctx JobContext) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.interrupted = append(l.interrupted, ctx.ExecutionID())
+}
+
+func (l *interruptListener) JobWasExecuted(ctx JobContext, result ExecutionResult) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.results = append(l.results, result)
+}
+
+func (l *interruptListener) executed() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.results)
+}
+
+func blockingExecutor(started chan<- string) JobExecutor {
+	return func(ctx JobContext) error {
+		started <- ctx.ExecutionID()
+		<-ctx.Context().Done()
+		return ctx.Context().Err()
+	}
+}
+
+func TestScheduler_InterruptJob(t *testing.T) {
+	Convey("Running execution must be interrupted", t, func() {
+		listener := &interruptListener{}
+		started := make(chan string, 1)
+		s := NewScheduler(
+			"interrupt",
+			WithTimers(Timers{TriggerStealTimeout: 50 * time.Millisecond}),
+			WithJobListener(listener),
+		)
+		s.RegisterExecutor("type", blockingExecutor(started))
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("* * * * * *").WithRepeats(1),
+		), ShouldBeNil)
+
+		ok, err := s.InterruptJob("j1")
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeFalse)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		var id string
+		select {
+		case id = <-started:
+		case <-time.After(3 * time.Second):
+		}
+		So(id, ShouldNotBeEmpty)
+
+		ok, err = s.InterruptJob("j1")
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(waitFor(func() bool { return listener.executed() == 1 }), ShouldBeTrue)
+
+		So(listener.interrupted, ShouldResemble, []string{id})
+		So(listener.results[0].Err, ShouldEqual, ErrJobInterrupted)
+
+		execution, err := s.GetExecution(id)
+		So(err, ShouldBeNil)
+		So(execution.Status, ShouldEqual, executions.StatusInterrupted)
+
+		ok, err = s.InterruptExecution(id)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Execution running on other clustered instance must be interrupted", t, func() {
+		store := stores.NewInMemoryStore()
+		listener := &interruptListener{}
+		started := make(chan string, 1)
+		running := NewScheduler(
+			clusterSName,
+			WithStore(store),
+			WithClustering(),
+			WithInstanceID("node0"),
+			WithTimers(clusterTimers),
+			WithJobListener(listener),
+		)
+		running.RegisterExecutor("type", blockingExecutor(started))
+		other := NewScheduler(clusterSName, WithStore(store), WithClustering(), WithInstanceID("node1"))
+
+		So(running.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("* * * * * *").WithRepeats(1),
+		), ShouldBeNil)
+		running.Start()
+		defer running.Shutdown(context.Background())
+
+		var id string
+		select {
+		case id = <-started:
+		case <-time.After(3 * time.Second):
+		}
+		So(id, ShouldNotBeEmpty)
+
+		ok, err := other.InterruptExecution(id)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(waitFor(func() bool { return listener.executed() == 1 }), ShouldBeTrue)
+
+		So(listener.interrupted, ShouldResemble, []string{id})
+		execution, err := other.GetExecution(id)
+		So(err, ShouldBeNil)
+		So(execution.Status, ShouldEqual, executions.StatusInterrupted)
+	})
+	Convey("Execution of dead clustered instance must not be interrupted", t, func() {
+		store := stores.NewInMemoryStore()
+		history := store.(stores.ExecutionStore)
+		now := time.Now()
+		So(history.InsertExecution(clusterSName, executions.Execution{
+			ID: "e1", JobKey: "j1", InstanceID: "dead", StartedAt: now.Add(-time.Hour), Status: executions.StatusRunning,
+		}), ShouldBeNil)
+		So(history.InsertExecution(clusterSName, executions.Execution{
+			ID: "e2", JobKey: "j1", InstanceID: "live", StartedAt: now.Add(-time.Hour), Status: executions.StatusRunning,
+		}), ShouldBeNil)
+		So(store.Heartbeat(clusterSName, "dead", now.Add(-time.Minute)), ShouldBeNil)
+		So(store.Heartbeat(clusterSName, "live", now), ShouldBeNil)
+		s := NewScheduler(clusterSName, WithStore(store), WithClustering(), WithInstanceID("node0"), WithTimers(clusterTimers))
+
+		ok, err := s.InterruptExecution("e1")
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeFalse)
+
+		ok, err = s.InterruptJob("j1")
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+
+		ids, err := history.GetInterruptRequests(clusterSName, "dead")
+		So(err, ShouldBeNil)
+		So(ids, ShouldBeEmpty)
+		ids, err = history.GetInterruptRequests(clusterSName, "live")
+		So(err, ShouldBeNil)
+		So(ids, ShouldResemble, []string{"e2"})
+	})
+}
@@ -1,10 +1,13 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
+	"github.com/d1slike/go-sched/internal"
 	"github.com/d1slike/go-sched/jobs"
 	"github.com/d1slike/go-sched/json"
 	"github.com/d1slike/go-sched/triggers"
+	"sync"
 )
 
 var (
@@ -12,15 +15,34 @@ var (
 )
 
 type JobContext interface {
+	// done when execution deadline exceeded or scheduler is shutting down
+	Context() context.Context
 	Trigger() triggers.ImmutableTrigger
 	Job() jobs.ImmutableJob
 	UnmarshalJobData(ptr interface{}) error
 	UnmarshalTriggerData(ptr interface{}) error
+	// number of current attempt of execution, starts from 1
+	Attempt() int
+	// error of previous failed attempt, nil for first attempt
+	LastError() error
+	// id of current execution, could be used to interrupt it
+	ExecutionID() string
+	// set result payload saved to execution history, see stores.ExecutionStore
+	SetResult(data interface{}) error
 }
 
 type jobCtx struct {
-	job     jobs.ImmutableJob
-	trigger triggers.ImmutableTrigger
+	ctx         context.Context
+	job         jobs.ImmutableJob
+	trigger     triggers.ImmutableTrigger
+	executionID string
+
+	lock   sync.Mutex
+	result []byte
+}
+
+func (ctx *jobCtx) Context() context.Context {
+	return ctx.ctx
 }
 
 func (ctx *jobCtx) Trigger() triggers.ImmutableTrigger {
@@ -44,3 +66,37 @@ func (ctx *jobCtx) UnmarshalTriggerData(ptr interface{}) error {
 	}
 	return json.Provider.Unmarshal(ctx.trigger.Data(), ptr)
 }
+
+func (ctx *jobCtx) Attempt() int {
+	return ctx.trigger.FailedAttempts() + 1
+}
+
+func (ctx *jobCtx) LastError() error {
+	if ctx.trigger.LastError() == "" {
+		return nil
+	}
+	return errors.New(ctx.trigger.LastError())
+}
+
+func (ctx *jobCtx) ExecutionID() string {
+	return ctx.executionID
+}
+
+func (ctx *jobCtx) SetResult(data interface{}) error {
+	result, err := internal.CastData(data)
+	if err != nil {
+		return err
+	}
+
+	ctx.lock.Lock()
+	ctx.result = result
+	ctx.lock.Unlock()
+
+	return nil
+}
+
+func (ctx *jobCtx) getResult() []byte {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	return ctx.result
+}
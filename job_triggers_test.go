@@ -0,0 +1,99 @@
+package scheduler
+
+import (
+	"github.com/d1slike/go-sched/jobs"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"sort"
+	"testing"
+)
+
+func triggerKeys(arr []triggers.ImmutableTrigger) []string {
+	keys := make([]string, 0, len(arr))
+	for _, t := range arr {
+		keys = append(keys, t.Key())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestScheduler_JobTriggers(t *testing.T) {
+	Convey("Job could have multiple triggers", t, func() {
+		s := NewScheduler("job_triggers")
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 0 * * * *"),
+		), ShouldBeNil)
+
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithCron("0 30 * * * *")), ShouldBeNil)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithCron("0 30 * * * *")), ShouldEqual, stores.ErrTriggerAlreadyExists)
+		So(s.AddTrigger("unknown", NewTrigger().WithKey("t3").WithCron("0 30 * * * *")), ShouldEqual, stores.ErrJobNotFound)
+
+		arr, err := s.GetTriggersOfJob("j1")
+		So(err, ShouldBeNil)
+		So(triggerKeys(arr), ShouldResemble, []string{"t1", "t2"})
+
+		Convey("job must be kept after its last trigger is deleted by default", func() {
+			for _, key := range []string{"t1", "t2"} {
+				ok, err := s.DeleteTrigger(key)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+			}
+			job, err := s.GetJob("j1")
+			So(err, ShouldBeNil)
+			So(job, ShouldNotBeNil)
+			So(job.DeletedWithLastTrigger(), ShouldBeFalse)
+		})
+
+		Convey("opted in job must be deleted with its last trigger", func() {
+			So(s.ScheduleJob(
+				NewJob().WithKey("j2").WithType("type").DeleteWithLastTrigger(),
+				NewTrigger().WithKey("t3").WithCron("0 0 * * * *"),
+			), ShouldBeNil)
+			So(s.AddTrigger("j2", NewTrigger().WithKey("t4").WithCron("0 30 * * * *")), ShouldBeNil)
+
+			ok, err := s.DeleteTrigger("t3")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			job, err := s.GetJob("j2")
+			So(err, ShouldBeNil)
+			So(job, ShouldNotBeNil)
+
+			ok, err = s.DeleteTrigger("t4")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			job, err = s.GetJob("j2")
+			So(err, ShouldBeNil)
+			So(job, ShouldBeNil)
+		})
+
+		Convey("failed scheduling must not leave orphan job", func() {
+			err := s.ScheduleJob(
+				NewJob().WithKey("j2").WithType("type"),
+				NewTrigger().WithKey("t1").WithCron("0 0 * * * *"),
+			)
+			So(err, ShouldEqual, stores.ErrTriggerAlreadyExists)
+
+			job, err := s.GetJob("j2")
+			So(err, ShouldBeNil)
+			So(job, ShouldBeNil)
+		})
+
+		Convey("durable job must exist without triggers", func() {
+			So(s.AddJob(NewJob().WithKey("j2").WithType("type")), ShouldEqual, jobs.ErrNotDurable)
+			So(s.AddJob(NewJob().WithKey("j2").WithType("type").StoreDurably().DeleteWithLastTrigger()), ShouldBeNil)
+			So(s.AddJob(NewJob().WithKey("j2").WithType("type").StoreDurably()), ShouldEqual, stores.ErrJobAlreadyExists)
+
+			So(s.AddTrigger("j2", NewTrigger().WithKey("t3").WithCron("0 0 * * * *")), ShouldBeNil)
+			ok, err := s.DeleteTrigger("t3")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			job, err := s.GetJob("j2")
+			So(err, ShouldBeNil)
+			So(job, ShouldNotBeNil)
+			So(job.Durable(), ShouldBeTrue)
+		})
+	})
+}
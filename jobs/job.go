@@ -1,16 +1,29 @@
 package jobs
 
-import "errors"
+import (
+	"errors"
+	"github.com/d1slike/go-sched/retry"
+	"time"
+)
 
 var (
 	ErrEmptyJobKey  = errors.New("empty job key")
 	ErrEmptyJobType = errors.New("empty job type")
+	ErrNotDurable   = errors.New("job without triggers must be durable")
 )
 
 type MutableJob interface {
 	WithData(data interface{}) MutableJob
 	WithKey(jKey string) MutableJob
 	WithType(jType string) MutableJob
+	WithTimeout(timeout time.Duration) MutableJob
+	WithRetryPolicy(policy retry.Policy) MutableJob
+	// at most one execution of job may run at once across all triggers and scheduler instances
+	DisallowConcurrentExecution() MutableJob
+	// keep job in store when it has no triggers
+	StoreDurably() MutableJob
+	// delete not durable job when its last trigger is deleted by Scheduler.DeleteTrigger, job is kept by default
+	DeleteWithLastTrigger() MutableJob
 	ToImmutable() (ImmutableJob, error)
 }
 
@@ -18,4 +31,9 @@ type ImmutableJob interface {
 	Key() string
 	Type() string
 	Data() []byte
+	Timeout() time.Duration
+	RetryPolicy() *retry.Policy
+	ConcurrentExecutionDisallowed() bool
+	Durable() bool
+	DeletedWithLastTrigger() bool
 }
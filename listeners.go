@@ -0,0 +1,156 @@
+package scheduler
+
+import (
+	"github.com/d1slike/go-sched/jobs"
+	"github.com/d1slike/go-sched/log"
+	"github.com/d1slike/go-sched/triggers"
+	"time"
+)
+
+type ExecutionResult struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Err       error
+}
+
+type JobListener interface {
+	JobToBeExecuted(ctx JobContext)
+	JobWasExecuted(ctx JobContext, result ExecutionResult)
+	// called on instance running job when its interruption is requested
+	JobWasInterrupted(ctx JobContext)
+}
+
+type TriggerListener interface {
+	TriggerMisfired(trigger triggers.ImmutableTrigger)
+	TriggerExhausted(trigger triggers.ImmutableTrigger)
+	TriggerPaused(trigger triggers.ImmutableTrigger)
+	TriggerResumed(trigger triggers.ImmutableTrigger)
+}
+
+type SchedulerListener interface {
+	SchedulerStarted()
+	SchedulerShuttingDown()
+	// firing is retried after Timers.TriggerRecoveryInterval
+	ExecutorNotFound(job jobs.ImmutableJob, trigger triggers.ImmutableTrigger)
+}
+
+// embed nop listeners to implement only needed methods
+
+type NopJobListener struct {
+}
+
+func (NopJobListener) JobToBeExecuted(ctx JobContext) {
+}
+
+func (NopJobListener) JobWasExecuted(ctx JobContext, result ExecutionResult) {
+}
+
+func (NopJobListener) JobWasInterrupted(ctx JobContext) {
+}
+
+type NopTriggerListener struct {
+}
+
+func (NopTriggerListener) TriggerMisfired(trigger triggers.ImmutableTrigger) {
+}
+
+func (NopTriggerListener) TriggerExhausted(trigger triggers.ImmutableTrigger) {
+}
+
+func (NopTriggerListener) TriggerPaused(trigger triggers.ImmutableTrigger) {
+}
+
+func (NopTriggerListener) TriggerResumed(trigger triggers.ImmutableTrigger) {
+}
+
+type NopSchedulerListener struct {
+}
+
+func (NopSchedulerListener) SchedulerStarted() {
+}
+
+func (NopSchedulerListener) SchedulerShuttingDown() {
+}
+
+func (NopSchedulerListener) ExecutorNotFound(job jobs.ImmutableJob, trigger triggers.ImmutableTrigger) {
+}
+
+type listenerRegistry struct {
+	jobListeners       []JobListener
+	triggerListeners   []TriggerListener
+	schedulerListeners []SchedulerListener
+}
+
+func (r *listenerRegistry) jobToBeExecuted(ctx JobContext) {
+	for _, l := range r.jobListeners {
+		notify(func() { l.JobToBeExecuted(ctx) })
+	}
+}
+
+func (r *listenerRegistry) jobWasExecuted(ctx JobContext, result ExecutionResult) {
+	for _, l := range r.jobListeners {
+		notify(func() { l.JobWasExecuted(ctx, result) })
+	}
+}
+
+func (r *listenerRegistry) jobWasInterrupted(ctx JobContext) {
+	for _, l := range r.jobListeners {
+		notify(func() { l.JobWasInterrupted(ctx) })
+	}
+}
+
+func (r *listenerRegistry) triggerMisfired(t triggers.ImmutableTrigger) {
+	for _, l := range r.triggerListeners {
+		notify(func() { l.TriggerMisfired(t) })
+	}
+}
+
+func (r *listenerRegistry) triggerExhausted(t triggers.ImmutableTrigger) {
+	for _, l := range r.triggerListeners {
+		notify(func() { l.TriggerExhausted(t) })
+	}
+}
+
+func (r *listenerRegistry) triggerPaused(t triggers.ImmutableTrigger) {
+	for _, l := range r.triggerListeners {
+		notify(func() { l.TriggerPaused(t) })
+	}
+}
+
+func (r *listenerRegistry) triggerResumed(t triggers.ImmutableTrigger) {
+	for _, l := range r.triggerListeners {
+		notify(func() { l.TriggerResumed(t) })
+	}
+}
+
+func (r *listenerRegistry) schedulerStarted() {
+	for _, l := range r.schedulerListeners {
+		notify(l.SchedulerStarted)
+	}
+}
+
+func (r *listenerRegistry) schedulerShuttingDown() {
+	for _, l := range r.schedulerListeners {
+		notify(l.SchedulerShuttingDown)
+	}
+}
+
+func (r *listenerRegistry) executorNotFound(job jobs.ImmutableJob, t triggers.ImmutableTrigger) {
+	for _, l := range r.schedulerListeners {
+		notify(func() { l.ExecutorNotFound(job, t) })
+	}
+}
+
+// listener must not break executor
+func notify(f func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("listenerRegistry: listener has panicked: %v", err)
+		}
+	}()
+	f()
+}
+
+func newListenerRegistry() *listenerRegistry {
+	return &listenerRegistry{}
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/d1slike/go-sched/clock"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingListener struct {
+	NopJobListener
+	NopSchedulerListener
+
+	lock    sync.Mutex
+	events  []string
+	results []ExecutionResult
+}
+
+func (l *recordingListener) record(event string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *recordingListener) JobToBeExecuted(ctx JobContext) {
+	l.record("before")
+}
+
+func (l *recordingListener) JobWasExecuted(ctx JobContext, result ExecutionResult) {
+	l.lock.Lock()
+	l.results = append(l.results, result)
+	l.lock.Unlock()
+	l.record("after")
+}
+
+func (l *recordingListener) SchedulerStarted() {
+	l.record("started")
+}
+
+func (l *recordingListener) SchedulerShuttingDown() {
+	l.record("shutting_down")
+}
+
+type panickingListener struct {
+	NopJobListener
+}
+
+func (panickingListener) JobToBeExecuted(ctx JobContext) {
+	panic("listener failure")
+}
+
+func (l *recordingListener) executed() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return len(l.results)
+}
+
+func TestListeners(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second}
+
+	Convey("Listeners must be notified about lifecycle events", t, func() {
+		fake := clock.NewFake(start)
+		listener := &recordingListener{}
+		jobErr := errors.New("job failure")
+		s := NewScheduler(
+			"listeners",
+			WithJobListener(panickingListener{}),
+			WithJobListener(listener),
+			WithSchedulerListener(listener),
+			WithClock(fake),
+			WithTimers(timers),
+		)
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			return jobErr
+		})
+		err := s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("* * * * * *").InLocation("UTC").WithRepeats(1),
+		)
+		So(err, ShouldBeNil)
+
+		s.Start()
+		fireAt(s, fake, timers, "t1", start.Add(time.Second))
+		So(waitFor(func() bool { return listener.executed() == 1 }), ShouldBeTrue)
+		So(s.Shutdown(context.Background()), ShouldBeNil)
+
+		listener.lock.Lock()
+		defer listener.lock.Unlock()
+		So(listener.events, ShouldResemble, []string{"started", "before", "after", "shutting_down"})
+		So(listener.results, ShouldHaveLength, 1)
+		So(listener.results[0].Err, ShouldEqual, jobErr)
+		So(listener.results[0].StartedAt.Equal(start.Add(time.Second)), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	hourly          = "0 0 * * * *"
+	everyTwoSeconds = "*/2 * * * * *"
+)
+
+var misfireStart = time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+
+// trigger has missed fire times of last 10 seconds, fake clock is moved until done
+func runMisfired(
+	spec string,
+	instruction triggers.MisfireInstruction,
+	repeats triggers.Repeats,
+	done func(fired int32, tr triggers.ImmutableTrigger) bool,
+) (int32, triggers.ImmutableTrigger, time.Time) {
+	store := stores.NewInMemoryStore()
+	fake := clock.NewFake(misfireStart)
+	timers := Timers{TriggerStealTimeout: 50 * time.Millisecond, MisfireThreshold: time.Second}
+	var fired int32
+	s := NewScheduler(
+		"misfire",
+		WithStore(store),
+		WithClock(fake),
+		WithTimers(timers),
+	)
+	s.RegisterExecutor("type", func(ctx JobContext) error {
+		atomic.AddInt32(&fired, 1)
+		return nil
+	})
+
+	tr, err := NewTrigger().
+		WithKey("t1").
+		WithCron(spec).
+		InLocation("UTC").
+		WithRepeats(repeats).
+		WithMisfireInstruction(instruction).
+		ToImmutable()
+	So(err, ShouldBeNil)
+	tr = internal.ModifyTrigger(tr, func(tr *internal.Trigger) {
+		tr.TjobKey = "j1"
+		tr.Tstate = triggers.StateScheduled
+		tr.TnextTime = fake.Now().Add(-10 * time.Second)
+	})
+	j, err := NewJob().WithKey("j1").WithType("type").ToImmutable()
+	So(err, ShouldBeNil)
+	So(store.InsertJob("misfire", j), ShouldBeNil)
+	So(store.InsertTrigger("misfire", tr), ShouldBeNil)
+
+	s.Start()
+	for i := 0; i < 1000; i++ {
+		tr, err = store.GetTrigger("misfire", "t1")
+		So(err, ShouldBeNil)
+		if done(atomic.LoadInt32(&fired), tr) {
+			break
+		}
+		fake.Advance(timers.TriggerStealTimeout)
+		time.Sleep(time.Millisecond)
+	}
+	So(s.Shutdown(context.Background()), ShouldBeNil)
+
+	tr, err = store.GetTrigger("misfire", "t1")
+	So(err, ShouldBeNil)
+	return atomic.LoadInt32(&fired), tr, fake.Now()
+}
+
+func TestMisfireInstructions(t *testing.T) {
+	Convey("Misfired trigger must be handled according to its instruction", t, func() {
+		Convey("fire now", func() {
+			fired, tr, now := runMisfired(hourly, triggers.MisfireFireNow, triggers.RepeatInfinity, func(fired int32, tr triggers.ImmutableTrigger) bool {
+				return tr.TriggeredTimes() == 1
+			})
+			So(fired, ShouldEqual, 1)
+			So(tr.NextTriggerTime().After(now), ShouldBeTrue)
+		})
+
+		Convey("fire all", func() {
+			fired, tr, _ := runMisfired(everyTwoSeconds, triggers.MisfireFireAll, triggers.RepeatInfinity, func(fired int32, tr triggers.ImmutableTrigger) bool {
+				return tr.TriggeredTimes() >= 5
+			})
+			So(fired, ShouldBeGreaterThanOrEqualTo, 5)
+			So(tr.TriggeredTimes(), ShouldEqual, fired)
+		})
+
+		Convey("skip", func() {
+			fired, tr, now := runMisfired(hourly, triggers.MisfireSkip, triggers.RepeatInfinity, func(fired int32, tr triggers.ImmutableTrigger) bool {
+				return tr.NextTriggerTime().After(misfireStart)
+			})
+			So(fired, ShouldEqual, 0)
+			So(tr.TriggeredTimes(), ShouldEqual, 0)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+			So(tr.NextTriggerTime().After(now), ShouldBeTrue)
+		})
+
+		Convey("do nothing", func() {
+			fired, tr, _ := runMisfired(everyTwoSeconds, triggers.MisfireDoNothing, triggers.Repeat(3), func(fired int32, tr triggers.ImmutableTrigger) bool {
+				return tr.State() == triggers.StateExhausted
+			})
+			So(fired, ShouldEqual, 0)
+			So(tr.TriggeredTimes(), ShouldEqual, 3)
+			So(tr.State(), ShouldEqual, triggers.StateExhausted)
+		})
+	})
+
+	Convey("Unknown misfire instruction must be rejected", t, func() {
+		_, err := NewTrigger().WithKey("t1").WithCron("* * * * * *").WithMisfireInstruction("UNKNOWN").ToImmutable()
+		So(err, ShouldNotBeNil)
+	})
+}
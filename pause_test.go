@@ -0,0 +1,151 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestScheduler_PauseResume(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second, MisfireThreshold: 5 * time.Second}
+	minute := func(m int) time.Time {
+		return start.Truncate(time.Minute).Add(time.Duration(m) * time.Minute)
+	}
+
+	Convey("Paused trigger must not fire and must keep its state", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 10)
+		s := NewScheduler(
+			"pause",
+			WithClock(fake),
+			WithTimers(timers),
+			WithMisfireInstruction(triggers.MisfireSkip),
+		)
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().NextTriggerTime()
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fireAt(s, fake, timers, "t1", minute(1))
+		So((<-fired).Equal(minute(1)), ShouldBeTrue)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+
+		So(s.PauseJob("j1"), ShouldBeNil)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.State(), ShouldEqual, triggers.StatePaused)
+
+		for fake.Now().Before(minute(4)) {
+			fake.Advance(timers.TriggerStealTimeout)
+			time.Sleep(time.Millisecond)
+		}
+		So(fired, ShouldBeEmpty)
+		tr, err = s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.State(), ShouldEqual, triggers.StatePaused)
+		So(tr.TriggeredTimes(), ShouldEqual, 1)
+
+		So(s.ResumeTrigger("t1"), ShouldBeNil)
+		tr, err = s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.State(), ShouldEqual, triggers.StateScheduled)
+		//fire times missed while paused are kept until trigger is acquired again
+		So(tr.NextTriggerTime().Equal(minute(2)), ShouldBeTrue)
+
+		//and then skipped by misfire instruction instead of being fired one by one
+		var firedAt time.Time
+		for i := 0; firedAt.IsZero() && i < 200; i++ {
+			fake.Advance(timers.TriggerStealTimeout)
+			select {
+			case firedAt = <-fired:
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		So(firedAt.Equal(minute(5)), ShouldBeTrue)
+		So(waitFor(triggeredTimes(s, "t1", 2)), ShouldBeTrue)
+
+		So(s.PauseTrigger("unknown"), ShouldNotBeNil)
+	})
+}
+
+func TestScheduler_PauseAll(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second}
+	state := func(s Scheduler, tKey string) triggers.TriggerState {
+		tr, err := s.GetTrigger(tKey)
+		So(err, ShouldBeNil)
+		return tr.State()
+	}
+
+	Convey("PauseAll and ResumeAll must change only existing triggers", t, func() {
+		s := NewScheduler("pause", WithClock(clock.NewFake(start)))
+		So(s.ScheduleJob(NewJob().WithKey("j1").WithType("type"), NewTrigger().WithKey("t1").WithCron("0 * * * * *")), ShouldBeNil)
+		So(s.ScheduleJob(NewJob().WithKey("j2").WithType("type"), NewTrigger().WithKey("t2").WithCron("0 * * * * *")), ShouldBeNil)
+
+		So(s.PauseAll(), ShouldBeNil)
+		So(state(s, "t1"), ShouldEqual, triggers.StatePaused)
+		So(state(s, "t2"), ShouldEqual, triggers.StatePaused)
+
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t3").WithCron("0 * * * * *")), ShouldBeNil)
+		So(state(s, "t3"), ShouldEqual, triggers.StateScheduled)
+
+		So(s.ResumeJob("j2"), ShouldBeNil)
+		So(state(s, "t1"), ShouldEqual, triggers.StatePaused)
+		So(state(s, "t2"), ShouldEqual, triggers.StateScheduled)
+
+		So(s.ResumeAll(), ShouldBeNil)
+		So(state(s, "t1"), ShouldEqual, triggers.StateScheduled)
+		So(state(s, "t3"), ShouldEqual, triggers.StateScheduled)
+	})
+
+	Convey("Trigger paused while its job is running must keep result of execution", t, func() {
+		fake := clock.NewFake(start)
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		s := NewScheduler("pause", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(time.Minute)
+		<-started
+
+		So(s.PauseAll(), ShouldBeNil)
+		So(state(s, "t1"), ShouldEqual, triggers.StatePaused)
+		close(release)
+
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.State(), ShouldEqual, triggers.StatePaused)
+		So(tr.AcquiredBy(), ShouldBeEmpty)
+		So(tr.NextTriggerTime().Equal(start.Truncate(time.Minute).Add(2*time.Minute)), ShouldBeTrue)
+
+		So(s.ResumeAll(), ShouldBeNil)
+		So(state(s, "t1"), ShouldEqual, triggers.StateScheduled)
+	})
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RescheduleTrigger(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second}
+	hourly := NewTrigger().WithCron("0 0 * * * *").InLocation("UTC")
+
+	Convey("Pending trigger must be rescheduled", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := NewScheduler("reschedule", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().NextTriggerTime()
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+		So(s.RescheduleTrigger("unknown", hourly, false), ShouldNotBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(time.Minute)
+		<-fired
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+
+		So(s.RescheduleTrigger("t1", hourly, false), ShouldBeNil)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.CronSpec(), ShouldEqual, "0 0 * * * *")
+		So(tr.JobKey(), ShouldEqual, "j1")
+		So(tr.State(), ShouldEqual, triggers.StateScheduled)
+		So(tr.TriggeredTimes(), ShouldEqual, 1)
+		So(tr.NextTriggerTime().Equal(start.Truncate(time.Hour).Add(time.Hour)), ShouldBeTrue)
+
+		//canceled future must not fire at previous schedule
+		fake.Advance(time.Minute)
+		time.Sleep(50 * time.Millisecond)
+		So(fired, ShouldBeEmpty)
+
+		So(s.RescheduleTrigger("t1", hourly, true), ShouldBeNil)
+		tr, err = s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.TriggeredTimes(), ShouldEqual, 0)
+	})
+
+	Convey("Running trigger must get next time by new schedule after execution", t, func() {
+		fake := clock.NewFake(start)
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		s := NewScheduler("reschedule", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, "t1")), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(time.Minute)
+		<-started
+
+		So(s.RescheduleTrigger("t1", hourly, false), ShouldBeNil)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.State(), ShouldEqual, triggers.StateAcquired)
+
+		close(release)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+		tr, err = s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.NextTriggerTime().Equal(start.Truncate(time.Hour).Add(time.Hour)), ShouldBeTrue)
+	})
+}
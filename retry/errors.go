@@ -0,0 +1,44 @@
+package retry
+
+import "errors"
+
+// errors implementing this interface decide on their own whether they are retryable
+type Classifier interface {
+	Retryable() bool
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Retryable() bool {
+	return false
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// mark error as not retryable
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// any error is retryable unless it was marked as permanent or classifies itself as not retryable
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var c Classifier
+	if errors.As(err, &c) {
+		return c.Retryable()
+	}
+	return true
+}
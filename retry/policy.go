@@ -0,0 +1,84 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	BackoffFixed       = Backoff("FIXED")
+	BackoffExponential = Backoff("EXPONENTIAL")
+)
+
+const (
+	defaultMultiplier = 2
+)
+
+type Backoff string
+
+type Policy struct {
+	// total attempts count including first execution
+	MaxAttempts int
+	Backoff     Backoff
+	// delay before first retry
+	Interval time.Duration
+	// upper bound of delay, zero means no bound
+	MaxInterval time.Duration
+	// growth factor of exponential backoff, 2 if not set
+	Multiplier float64
+	// randomization factor in [0, 1], delay is spread to [delay*(1-jitter), delay*(1+jitter)]
+	Jitter float64
+}
+
+// decide whether execution failed with err may be retried after given count of failed attempts
+func (p *Policy) ShouldRetry(failedAttempts int, err error) bool {
+	if p == nil || err == nil {
+		return false
+	}
+	return failedAttempts < p.MaxAttempts && IsRetryable(err)
+}
+
+// delay before next attempt after given count of failed attempts
+func (p *Policy) Delay(failedAttempts int) time.Duration {
+	if failedAttempts < 1 {
+		failedAttempts = 1
+	}
+
+	delay := float64(p.Interval)
+	if p.Backoff == BackoffExponential {
+		multiplier := p.Multiplier
+		if multiplier <= 1 {
+			multiplier = defaultMultiplier
+		}
+		delay *= math.Pow(multiplier, float64(failedAttempts-1))
+	}
+	if p.MaxInterval > 0 && delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay += delay * jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+func Fixed(maxAttempts int, interval time.Duration) Policy {
+	return Policy{
+		MaxAttempts: maxAttempts,
+		Backoff:     BackoffFixed,
+		Interval:    interval,
+	}
+}
+
+func Exponential(maxAttempts int, interval, maxInterval time.Duration) Policy {
+	return Policy{
+		MaxAttempts: maxAttempts,
+		Backoff:     BackoffExponential,
+		Interval:    interval,
+		MaxInterval: maxInterval,
+		Multiplier:  defaultMultiplier,
+	}
+}
@@ -0,0 +1,62 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestPolicy_Delay(t *testing.T) {
+	Convey("Test backoff delays", t, func() {
+		Convey("fixed backoff must return same delay", func() {
+			p := Fixed(3, time.Second)
+			So(p.Delay(1), ShouldEqual, time.Second)
+			So(p.Delay(5), ShouldEqual, time.Second)
+		})
+
+		Convey("exponential backoff must grow up to max interval", func() {
+			p := Exponential(10, time.Second, 10*time.Second)
+			So(p.Delay(1), ShouldEqual, time.Second)
+			So(p.Delay(2), ShouldEqual, 2*time.Second)
+			So(p.Delay(3), ShouldEqual, 4*time.Second)
+			So(p.Delay(5), ShouldEqual, 10*time.Second)
+		})
+
+		Convey("jitter must keep delay in bounds", func() {
+			p := Fixed(3, time.Second)
+			p.Jitter = 0.5
+			for i := 0; i < 100; i++ {
+				d := p.Delay(1)
+				So(d, ShouldBeBetweenOrEqual, 500*time.Millisecond, 1500*time.Millisecond)
+			}
+		})
+	})
+}
+
+func TestPolicy_ShouldRetry(t *testing.T) {
+	err := errors.New("failed")
+
+	Convey("Test retry decision", t, func() {
+		Convey("nil policy never retries", func() {
+			var p *Policy
+			So(p.ShouldRetry(1, err), ShouldBeFalse)
+		})
+
+		Convey("must retry until attempts are exhausted", func() {
+			p := Fixed(3, time.Second)
+			So(p.ShouldRetry(1, err), ShouldBeTrue)
+			So(p.ShouldRetry(2, err), ShouldBeTrue)
+			So(p.ShouldRetry(3, err), ShouldBeFalse)
+			So(p.ShouldRetry(1, nil), ShouldBeFalse)
+		})
+
+		Convey("must not retry permanent errors", func() {
+			p := Fixed(3, time.Second)
+			So(p.ShouldRetry(1, Permanent(err)), ShouldBeFalse)
+			So(p.ShouldRetry(1, fmt.Errorf("wrapped: %w", Permanent(err))), ShouldBeFalse)
+			So(errors.Is(Permanent(err), err), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/retry"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+type attemptInfo struct {
+	attempt int
+	lastErr error
+	at      time.Time
+}
+
+// wait for the next steal of trigger and advance fake clock to its fire time
+func fireAt(s Scheduler, fake *clock.Fake, timers Timers, tKey string, fireTime time.Time) {
+	fake.BlockUntil(2)
+	fake.Advance(timers.TriggerStealTimeout)
+	So(waitFor(triggerAcquired(s, tKey)), ShouldBeTrue)
+	fake.BlockUntil(3)
+	fake.Advance(fireTime.Sub(fake.Now()))
+}
+
+func failedAttempts(s Scheduler, tKey string, n int) func() bool {
+	return func() bool {
+		tr, err := s.GetTrigger(tKey)
+		return err == nil && tr != nil && tr.FailedAttempts() == n
+	}
+}
+
+func TestScheduler_Retry(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second}
+	fireTime := start.Truncate(time.Minute).Add(time.Minute)
+
+	Convey("Failed job must be retried with backoff until it succeeds", t, func() {
+		fake := clock.NewFake(start)
+		attempts := make(chan attemptInfo, 1)
+		s := NewScheduler("retry", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			attempts <- attemptInfo{attempt: ctx.Attempt(), lastErr: ctx.LastError(), at: fake.Now()}
+			if ctx.Attempt() < 3 {
+				return fmt.Errorf("attempt %d", ctx.Attempt())
+			}
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type").WithRetryPolicy(retry.Exponential(3, 10*time.Second, time.Minute)),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fireAt(s, fake, timers, "t1", fireTime)
+		a := <-attempts
+		So(a.attempt, ShouldEqual, 1)
+		So(a.lastErr, ShouldBeNil)
+		So(a.at.Equal(fireTime), ShouldBeTrue)
+		So(waitFor(failedAttempts(s, "t1", 1)), ShouldBeTrue)
+
+		//failed attempts are kept in store between firings
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.LastError(), ShouldEqual, "attempt 1")
+		So(tr.TriggeredTimes(), ShouldEqual, 0)
+		retryTime := fireTime.Add(10 * time.Second)
+		So(tr.NextTriggerTime().Equal(retryTime), ShouldBeTrue)
+
+		fireAt(s, fake, timers, "t1", retryTime)
+		a = <-attempts
+		So(a.attempt, ShouldEqual, 2)
+		So(a.lastErr, ShouldResemble, errors.New("attempt 1"))
+		So(a.at.Equal(retryTime), ShouldBeTrue)
+		So(waitFor(failedAttempts(s, "t1", 2)), ShouldBeTrue)
+
+		//exponential backoff doubles delay
+		tr, err = s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.LastError(), ShouldEqual, "attempt 2")
+		retryTime = retryTime.Add(20 * time.Second)
+		So(tr.NextTriggerTime().Equal(retryTime), ShouldBeTrue)
+
+		fireAt(s, fake, timers, "t1", retryTime)
+		a = <-attempts
+		So(a.attempt, ShouldEqual, 3)
+		So(a.lastErr, ShouldResemble, errors.New("attempt 2"))
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+
+		//successful attempt resets retry state and returns trigger to its schedule
+		tr, err = s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.FailedAttempts(), ShouldEqual, 0)
+		So(tr.LastError(), ShouldBeEmpty)
+		So(tr.NextTriggerTime().Equal(fireTime.Add(time.Minute)), ShouldBeTrue)
+	})
+
+	Convey("Job failed with permanent error must not be retried", t, func() {
+		fake := clock.NewFake(start)
+		attempts := make(chan attemptInfo, 1)
+		s := NewScheduler("retry", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			attempts <- attemptInfo{attempt: ctx.Attempt(), lastErr: ctx.LastError(), at: fake.Now()}
+			return retry.Permanent(errors.New("bad input"))
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type").WithRetryPolicy(retry.Fixed(3, 10*time.Second)),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fireAt(s, fake, timers, "t1", fireTime)
+		So((<-attempts).attempt, ShouldEqual, 1)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.FailedAttempts(), ShouldEqual, 0)
+		So(tr.LastError(), ShouldBeEmpty)
+		So(tr.NextTriggerTime().Equal(fireTime.Add(time.Minute)), ShouldBeTrue)
+
+		//next firing starts from first attempt
+		fireAt(s, fake, timers, "t1", fireTime.Add(time.Minute))
+		So((<-attempts).attempt, ShouldEqual, 1)
+	})
+
+	Convey("Job must not be retried more than policy allows", t, func() {
+		fake := clock.NewFake(start)
+		attempts := make(chan attemptInfo, 1)
+		s := NewScheduler("retry", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			attempts <- attemptInfo{attempt: ctx.Attempt(), lastErr: ctx.LastError(), at: fake.Now()}
+			return errors.New("failed")
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type").WithRetryPolicy(retry.Fixed(2, 10*time.Second)),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fireAt(s, fake, timers, "t1", fireTime)
+		So((<-attempts).attempt, ShouldEqual, 1)
+		So(waitFor(failedAttempts(s, "t1", 1)), ShouldBeTrue)
+
+		fireAt(s, fake, timers, "t1", fireTime.Add(10*time.Second))
+		So((<-attempts).attempt, ShouldEqual, 2)
+		So(waitFor(triggeredTimes(s, "t1", 1)), ShouldBeTrue)
+
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.FailedAttempts(), ShouldEqual, 0)
+		So(tr.NextTriggerTime().Equal(fireTime.Add(time.Minute)), ShouldBeTrue)
+	})
+}
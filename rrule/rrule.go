@@ -0,0 +1,347 @@
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Yearly   = Frequency("YEARLY")
+	Monthly  = Frequency("MONTHLY")
+	Weekly   = Frequency("WEEKLY")
+	Daily    = Frequency("DAILY")
+	Hourly   = Frequency("HOURLY")
+	Minutely = Frequency("MINUTELY")
+	Secondly = Frequency("SECONDLY")
+)
+
+// max periods of rule scanned by single Schedule.Next call, rule which has no occurrence within them never fires
+const maxScannedPeriods = 100000
+
+var (
+	ErrEmptyRule = errors.New("empty recurrence rule")
+	ErrNoFreq    = errors.New("FREQ is required")
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+type Frequency string
+
+// weekday of BYDAY part, n is its ordinal within month or year, 0 means every such weekday
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+// date or date-time of rule, floating values are resolved in location of dtstart
+type value struct {
+	year, month, day     int
+	hour, minute, second int
+	utc, dateOnly        bool
+}
+
+type rule struct {
+	freq       Frequency
+	interval   int
+	count      int
+	until      *value
+	byMonth    []int
+	byMonthDay []int
+	byDay      []weekdayNum
+	byHour     []int
+	byMinute   []int
+	bySecond   []int
+	bySetPos   []int
+	wkst       time.Weekday
+}
+
+// recurrence set: RRULE with optional RDATE and EXDATE
+type Set struct {
+	rule    rule
+	rdates  []value
+	exdates []value
+}
+
+// parse RFC 5545 recurrence rule, e.g. "FREQ=MONTHLY;BYDAY=-1FR".
+// multi-line form with RRULE, RDATE and EXDATE properties is accepted too:
+//
+//	RRULE:FREQ=WEEKLY;BYDAY=MO,WE
+//	EXDATE:20240101T090000Z,20240103
+//	RDATE:20240106T090000
+func Parse(spec string) (*Set, error) {
+	set := &Set{}
+	hasRule := false
+
+	for _, line := range strings.FieldsFunc(spec, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, content := "RRULE", line
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			name, content = strings.ToUpper(line[:i]), line[i+1:]
+			//parameters like VALUE=DATE are derived from values
+			if j := strings.IndexByte(name, ';'); j >= 0 {
+				name = name[:j]
+			}
+		}
+
+		switch name {
+		case "RRULE":
+			if hasRule {
+				return nil, errors.New("only one RRULE is supported")
+			}
+			r, err := parseRule(content)
+			if err != nil {
+				return nil, err
+			}
+			set.rule = r
+			hasRule = true
+		case "RDATE", "EXDATE":
+			values, err := parseValues(content)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", name, err)
+			}
+			if name == "RDATE" {
+				set.rdates = append(set.rdates, values...)
+			} else {
+				set.exdates = append(set.exdates, values...)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported property %s", name)
+		}
+	}
+
+	if !hasRule {
+		return nil, ErrEmptyRule
+	}
+
+	return set, nil
+}
+
+func parseRule(s string) (rule, error) {
+	r := rule{interval: 1, wkst: time.Monday}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return r, fmt.Errorf("invalid rule part %q", part)
+		}
+		name, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch f := Frequency(val); f {
+			case Yearly, Monthly, Weekly, Daily, Hourly, Minutely, Secondly:
+				r.freq = f
+			default:
+				err = fmt.Errorf("unknown frequency")
+			}
+		case "INTERVAL":
+			r.interval, err = parsePositive(val)
+		case "COUNT":
+			r.count, err = parsePositive(val)
+		case "UNTIL":
+			var v value
+			if v, err = parseValue(val); err == nil {
+				r.until = &v
+			}
+		case "BYMONTH":
+			r.byMonth, err = parseInts(val, 1, 12, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(val, 1, 31, true)
+		case "BYDAY":
+			r.byDay, err = parseWeekdays(val)
+		case "BYHOUR":
+			r.byHour, err = parseInts(val, 0, 23, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseInts(val, 0, 59, false)
+		case "BYSECOND":
+			r.bySecond, err = parseInts(val, 0, 59, false)
+		case "BYSETPOS":
+			r.bySetPos, err = parseInts(val, 1, 366, true)
+		case "WKST":
+			day, ok := weekdays[val]
+			if !ok {
+				err = fmt.Errorf("unknown weekday")
+			}
+			r.wkst = day
+		case "BYYEARDAY", "BYWEEKNO":
+			err = fmt.Errorf("is not supported")
+		default:
+			err = fmt.Errorf("unknown rule part")
+		}
+		if err != nil {
+			return r, fmt.Errorf("invalid %s=%s: %v", name, kv[1], err)
+		}
+	}
+
+	if r.freq == "" {
+		return r, ErrNoFreq
+	}
+	if r.count > 0 && r.until != nil {
+		return r, errors.New("COUNT and UNTIL must not be used together")
+	}
+	if r.freq != Monthly && r.freq != Yearly {
+		for _, wd := range r.byDay {
+			if wd.n != 0 {
+				return r, fmt.Errorf("BYDAY ordinals are allowed only with MONTHLY or YEARLY frequency")
+			}
+		}
+	}
+	if r.freq == Weekly && len(r.byMonthDay) > 0 {
+		return r, fmt.Errorf("BYMONTHDAY must not be used with WEEKLY frequency")
+	}
+
+	return r, nil
+}
+
+func parsePositive(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("must be positive integer")
+	}
+	return n, nil
+}
+
+// parse comma separated list of values within [min, max], negative values within [-max, -min] if allowed
+func parseInts(s string, min, max int, negative bool) ([]int, error) {
+	arr := make([]int, 0)
+	for _, item := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not integer", item)
+		}
+		abs := n
+		if negative && n < 0 {
+			abs = -n
+		}
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("%d is out of range", n)
+		}
+		arr = append(arr, n)
+	}
+	return arr, nil
+}
+
+func parseWeekdays(s string) ([]weekdayNum, error) {
+	arr := make([]weekdayNum, 0)
+	for _, item := range strings.Split(s, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("%q is not weekday", item)
+		}
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("%q is not weekday", item)
+		}
+		wd := weekdayNum{day: day}
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("%q has invalid ordinal", item)
+			}
+			wd.n = n
+		}
+		arr = append(arr, wd)
+	}
+	return arr, nil
+}
+
+func parseValues(s string) ([]value, error) {
+	arr := make([]value, 0)
+	for _, item := range strings.Split(s, ",") {
+		v, err := parseValue(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+// parse date 20060102, floating date-time 20060102T150405 or UTC date-time 20060102T150405Z
+func parseValue(s string) (value, error) {
+	var (
+		v      value
+		layout string
+	)
+	switch {
+	case len(s) == 8:
+		layout, v.dateOnly = "20060102", true
+	case len(s) == 15:
+		layout = "20060102T150405"
+	case len(s) == 16 && (s[15] == 'Z' || s[15] == 'z'):
+		layout, v.utc = "20060102T150405", true
+		s = s[:15]
+	default:
+		return v, fmt.Errorf("%q is not date or date-time", s)
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return v, fmt.Errorf("%q is not date or date-time", s)
+	}
+	v.year, v.month, v.day = t.Year(), int(t.Month()), t.Day()
+	v.hour, v.minute, v.second = t.Hour(), t.Minute(), t.Second()
+
+	return v, nil
+}
+
+func (v value) in(loc *time.Location) time.Time {
+	if v.utc {
+		return time.Date(v.year, time.Month(v.month), v.day, v.hour, v.minute, v.second, 0, time.UTC).In(loc)
+	}
+	return localTime(v.year, time.Month(v.month), v.day, v.hour, v.minute, v.second, loc)
+}
+
+// schedule of recurrence set anchored to dtstart, which also gives location and default time parts of occurrences
+func (s *Set) Schedule(dtstart time.Time) *Schedule {
+	dtstart = dtstart.Truncate(time.Second)
+	loc := dtstart.Location()
+	sched := &Schedule{
+		rule:    s.rule,
+		dtstart: dtstart,
+		loc:     loc,
+	}
+
+	if s.rule.until != nil {
+		until := s.rule.until.in(loc)
+		if s.rule.until.dateOnly {
+			until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		sched.until = until
+	}
+	for _, v := range s.rdates {
+		t := v.in(loc)
+		if v.dateOnly {
+			t = localTime(v.year, time.Month(v.month), v.day, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), loc)
+		}
+		sched.rdates = append(sched.rdates, t)
+	}
+	sort.Slice(sched.rdates, func(i, j int) bool { return sched.rdates[i].Before(sched.rdates[j]) })
+	for _, v := range s.exdates {
+		if v.dateOnly {
+			sched.exdays = append(sched.exdays, v)
+		} else {
+			sched.exdates = append(sched.exdates, v.in(loc))
+		}
+	}
+
+	return sched
+}
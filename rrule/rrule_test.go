@@ -0,0 +1,168 @@
+package rrule
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func occurrences(t *testing.T, spec string, dtstart time.Time, n int) []string {
+	set, err := Parse(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sched := set.Schedule(dtstart)
+
+	arr := make([]string, 0, n)
+	after := dtstart.Add(-time.Nanosecond)
+	for i := 0; i < n; i++ {
+		next := sched.Next(after)
+		if next.IsZero() {
+			break
+		}
+		arr = append(arr, next.Format("2006-01-02T15:04:05Z07:00"))
+		after = next
+	}
+	return arr
+}
+
+func TestSchedule_Next(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dtstart := time.Date(1997, 9, 2, 9, 0, 0, 0, ny)
+
+	Convey("Test occurrences of RFC 5545 examples", t, func() {
+		Convey("daily for 10 occurrences", func() {
+			So(occurrences(t, "FREQ=DAILY;COUNT=10", dtstart, 20), ShouldResemble, []string{
+				"1997-09-02T09:00:00-04:00", "1997-09-03T09:00:00-04:00", "1997-09-04T09:00:00-04:00",
+				"1997-09-05T09:00:00-04:00", "1997-09-06T09:00:00-04:00", "1997-09-07T09:00:00-04:00",
+				"1997-09-08T09:00:00-04:00", "1997-09-09T09:00:00-04:00", "1997-09-10T09:00:00-04:00",
+				"1997-09-11T09:00:00-04:00",
+			})
+		})
+
+		Convey("every other week on Tuesday and Thursday for 8 occurrences", func() {
+			So(occurrences(t, "FREQ=WEEKLY;INTERVAL=2;WKST=SU;BYDAY=TU,TH;COUNT=8", dtstart, 20), ShouldResemble, []string{
+				"1997-09-02T09:00:00-04:00", "1997-09-04T09:00:00-04:00", "1997-09-16T09:00:00-04:00",
+				"1997-09-18T09:00:00-04:00", "1997-09-30T09:00:00-04:00", "1997-10-02T09:00:00-04:00",
+				"1997-10-14T09:00:00-04:00", "1997-10-16T09:00:00-04:00",
+			})
+		})
+
+		Convey("monthly on the second-to-last Monday for 6 months", func() {
+			So(occurrences(t, "FREQ=MONTHLY;COUNT=6;BYDAY=-2MO", dtstart, 20), ShouldResemble, []string{
+				"1997-09-22T09:00:00-04:00", "1997-10-20T09:00:00-04:00", "1997-11-17T09:00:00-05:00",
+				"1997-12-22T09:00:00-05:00", "1998-01-19T09:00:00-05:00", "1998-02-16T09:00:00-05:00",
+			})
+		})
+
+		Convey("monthly on the third-to-the-last day of the month", func() {
+			So(occurrences(t, "FREQ=MONTHLY;BYMONTHDAY=-3", dtstart, 4), ShouldResemble, []string{
+				"1997-09-28T09:00:00-04:00", "1997-10-29T09:00:00-05:00", "1997-11-28T09:00:00-05:00",
+				"1997-12-29T09:00:00-05:00",
+			})
+		})
+
+		Convey("second-to-last weekday of the month", func() {
+			So(occurrences(t, "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-2", dtstart, 4), ShouldResemble, []string{
+				"1997-09-29T09:00:00-04:00", "1997-10-30T09:00:00-05:00", "1997-11-27T09:00:00-05:00",
+				"1997-12-30T09:00:00-05:00",
+			})
+		})
+
+		Convey("yearly in June and July for 4 occurrences", func() {
+			start := time.Date(1997, 6, 10, 9, 0, 0, 0, ny)
+			So(occurrences(t, "FREQ=YEARLY;COUNT=4;BYMONTH=6,7", start, 20), ShouldResemble, []string{
+				"1997-06-10T09:00:00-04:00", "1997-07-10T09:00:00-04:00", "1998-06-10T09:00:00-04:00",
+				"1998-07-10T09:00:00-04:00",
+			})
+		})
+
+		Convey("every 20th Monday of the year", func() {
+			start := time.Date(1997, 5, 19, 9, 0, 0, 0, ny)
+			So(occurrences(t, "FREQ=YEARLY;BYDAY=20MO", start, 3), ShouldResemble, []string{
+				"1997-05-19T09:00:00-04:00", "1998-05-18T09:00:00-04:00", "1999-05-17T09:00:00-04:00",
+			})
+		})
+
+		Convey("every Friday the 13th excluding dtstart", func() {
+			spec := "RRULE:FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13\nEXDATE:19970902T090000"
+			So(occurrences(t, spec, dtstart, 4), ShouldResemble, []string{
+				"1998-02-13T09:00:00-05:00", "1998-03-13T09:00:00-05:00", "1998-11-13T09:00:00-05:00",
+				"1999-08-13T09:00:00-04:00",
+			})
+		})
+
+		Convey("every 3 hours until UTC time", func() {
+			So(occurrences(t, "FREQ=HOURLY;INTERVAL=3;UNTIL=19970902T170000Z", dtstart, 20), ShouldResemble, []string{
+				"1997-09-02T09:00:00-04:00", "1997-09-02T12:00:00-04:00",
+			})
+		})
+
+		Convey("every 20 minutes within working hours", func() {
+			So(occurrences(t, "FREQ=MINUTELY;INTERVAL=20;BYHOUR=9,10,11,12,13,14,15,16", dtstart, 26)[23:], ShouldResemble, []string{
+				"1997-09-02T16:40:00-04:00", "1997-09-03T09:00:00-04:00", "1997-09-03T09:20:00-04:00",
+			})
+		})
+	})
+
+	Convey("Test recurrence set", t, func() {
+		Convey("excluded dates must be counted and added dates must not", func() {
+			spec := "RRULE:FREQ=DAILY;COUNT=3\nEXDATE;VALUE=DATE:19970903\nRDATE:19970910T120000,19970902T090000"
+			So(occurrences(t, spec, dtstart, 20), ShouldResemble, []string{
+				"1997-09-02T09:00:00-04:00", "1997-09-04T09:00:00-04:00", "1997-09-10T12:00:00-04:00",
+			})
+		})
+
+		Convey("occurrences must not depend on time they are calculated from", func() {
+			set, err := Parse("FREQ=WEEKLY;INTERVAL=3;BYDAY=MO,WE")
+			So(err, ShouldBeNil)
+			sched := set.Schedule(dtstart)
+
+			after := dtstart
+			for i := 0; i < 200; i++ {
+				after = sched.Next(after)
+			}
+			So(sched.Next(after.Add(-time.Second)).Equal(after), ShouldBeTrue)
+			So(sched.Next(after.Add(time.Hour)).Sub(after), ShouldBeIn, []time.Duration{2 * 24 * time.Hour, 19 * 24 * time.Hour})
+		})
+
+		Convey("next time must be in location of given time", func() {
+			set, err := Parse("FREQ=DAILY")
+			So(err, ShouldBeNil)
+			next := set.Schedule(dtstart).Next(dtstart.UTC())
+			So(next.Location(), ShouldEqual, time.UTC)
+			So(next.Equal(dtstart.AddDate(0, 0, 1)), ShouldBeTrue)
+		})
+
+		Convey("exhausted rule must return zero time", func() {
+			set, err := Parse("FREQ=YEARLY;COUNT=1")
+			So(err, ShouldBeNil)
+			So(set.Schedule(dtstart).Next(dtstart).IsZero(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestParse(t *testing.T) {
+	Convey("Invalid rules must be rejected", t, func() {
+		for _, spec := range []string{
+			"",
+			"BYDAY=MO",
+			"FREQ=FORTNIGHTLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=MONTHLY;BYDAY=XX",
+			"FREQ=MONTHLY;BYMONTHDAY=0",
+			"FREQ=DAILY;COUNT=2;UNTIL=20000101",
+			"FREQ=YEARLY;BYWEEKNO=20",
+			"FREQ=DAILY;UNKNOWN=1",
+			"RRULE:FREQ=DAILY\nEXDATE:2000-01-01",
+			"RRULE:FREQ=DAILY\nDTSTART:20000101",
+		} {
+			_, err := Parse(spec)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
@@ -0,0 +1,482 @@
+package rrule
+
+import (
+	"github.com/d1slike/go-sched/utils"
+	"sort"
+	"time"
+)
+
+// date without time and location
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) date {
+	y, m, d := t.Date()
+	return date{year: y, month: m, day: d}
+}
+
+// recurrence set anchored to dtstart
+type Schedule struct {
+	rule    rule
+	dtstart time.Time
+	loc     *time.Location
+	until   time.Time
+	rdates  []time.Time
+	exdates []time.Time
+	exdays  []value
+}
+
+// return first occurrence of set strictly after given time in location of dtstart or zero time if there is none
+func (s *Schedule) Next(after time.Time) time.Time {
+	next := s.nextOccurrence(after)
+	for _, t := range s.rdates {
+		if !t.After(after) || s.excluded(t) {
+			continue
+		}
+		if next.IsZero() || t.Before(next) {
+			next = t
+		}
+		break
+	}
+	if next.IsZero() {
+		return next
+	}
+	return next.In(after.Location())
+}
+
+func (s *Schedule) nextOccurrence(after time.Time) time.Time {
+	count := 0
+	first := 0
+	//periods before given time could be skipped only if occurrences don't need to be counted
+	if s.rule.count == 0 {
+		first = s.periodIndex(after.In(s.loc)) - 1
+		if first < 0 {
+			first = 0
+		}
+	}
+
+	for k := first; k < first+maxScannedPeriods; k++ {
+		for _, t := range s.occurrences(k) {
+			if t.Before(s.dtstart) {
+				continue
+			}
+			if !s.until.IsZero() && t.After(s.until) {
+				return time.Time{}
+			}
+			count++
+			if s.rule.count > 0 && count > s.rule.count {
+				return time.Time{}
+			}
+			if t.After(after) && !s.excluded(t) {
+				return t
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) excluded(t time.Time) bool {
+	for _, ex := range s.exdates {
+		if ex.Equal(t) {
+			return true
+		}
+	}
+	if len(s.exdays) > 0 {
+		d := dateOf(t.In(s.loc))
+		for _, ex := range s.exdays {
+			if d.year == ex.year && int(d.month) == ex.month && d.day == ex.day {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// index of period containing given time, periods are counted from the one containing dtstart
+func (s *Schedule) periodIndex(t time.Time) int {
+	var n int
+	switch s.rule.freq {
+	case Yearly:
+		n = t.Year() - s.dtstart.Year()
+	case Monthly:
+		n = (t.Year()-s.dtstart.Year())*12 + int(t.Month()) - int(s.dtstart.Month())
+	case Weekly:
+		n = daysBetween(s.weekStart(dateOf(s.dtstart)), dateOf(t)) / 7
+	case Daily:
+		n = daysBetween(dateOf(s.dtstart), dateOf(t))
+	default:
+		n = int(t.Sub(s.subDailyStart()) / s.subDailyUnit())
+	}
+	if n < 0 {
+		return 0
+	}
+	return n / s.rule.interval
+}
+
+// occurrences of rule within k-th period sorted ascending
+func (s *Schedule) occurrences(k int) []time.Time {
+	step := k * s.rule.interval
+	start := dateOf(s.dtstart)
+
+	var arr []time.Time
+	switch s.rule.freq {
+	case Yearly:
+		arr = s.combine(s.yearDates(start.year+step), s.timesOfDay())
+	case Monthly:
+		m := int(start.month) - 1 + step
+		arr = s.combine(s.monthDates(start.year+m/12, time.Month(m%12+1)), s.timesOfDay())
+	case Weekly:
+		arr = s.combine(s.weekDates(addDays(s.weekStart(start), 7*step)), s.timesOfDay())
+	case Daily:
+		arr = s.combine(s.filterDates([]date{addDays(start, step)}), s.timesOfDay())
+	default:
+		arr = s.subDailyOccurrences(s.subDailyStart().Add(time.Duration(step) * s.subDailyUnit()).In(s.loc))
+	}
+
+	return s.applySetPos(arr)
+}
+
+func (s *Schedule) subDailyUnit() time.Duration {
+	switch s.rule.freq {
+	case Hourly:
+		return time.Hour
+	case Minutely:
+		return time.Minute
+	default:
+		return time.Second
+	}
+}
+
+func (s *Schedule) subDailyStart() time.Time {
+	d := s.dtstart
+	switch s.rule.freq {
+	case Hourly:
+		return time.Date(d.Year(), d.Month(), d.Day(), d.Hour(), 0, 0, 0, s.loc)
+	case Minutely:
+		return time.Date(d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(), 0, 0, s.loc)
+	default:
+		return d
+	}
+}
+
+func (s *Schedule) subDailyOccurrences(p time.Time) []time.Time {
+	if len(s.filterDates([]date{dateOf(p)})) == 0 {
+		return nil
+	}
+	r := s.rule
+	if len(r.byHour) > 0 && !contains(r.byHour, p.Hour()) {
+		return nil
+	}
+
+	minutes, seconds := []int{p.Minute()}, []int{p.Second()}
+	switch r.freq {
+	case Hourly:
+		minutes, seconds = or(r.byMinute, s.dtstart.Minute()), or(r.bySecond, s.dtstart.Second())
+	case Minutely:
+		if len(r.byMinute) > 0 && !contains(r.byMinute, p.Minute()) {
+			return nil
+		}
+		seconds = or(r.bySecond, s.dtstart.Second())
+	default:
+		if len(r.byMinute) > 0 && !contains(r.byMinute, p.Minute()) ||
+			len(r.bySecond) > 0 && !contains(r.bySecond, p.Second()) {
+			return nil
+		}
+	}
+
+	arr := make([]time.Time, 0, len(minutes)*len(seconds))
+	for _, m := range minutes {
+		for _, sec := range seconds {
+			//minutes and seconds are offsets within period, so occurrences stay within it on DST transitions
+			t := p.Add(time.Duration(m-p.Minute())*time.Minute + time.Duration(sec-p.Second())*time.Second)
+			arr = append(arr, t)
+		}
+	}
+	sortTimes(arr)
+
+	return arr
+}
+
+func (s *Schedule) yearDates(year int) []date {
+	r := s.rule
+	//weekdays ordinals are counted within year if they are not limited by months
+	if len(r.byDay) > 0 && len(r.byMonth) == 0 && len(r.byMonthDay) == 0 {
+		first := date{year: year, month: time.January, day: 1}
+		return weekdaysIn(first, daysBetween(first, date{year: year + 1, month: time.January, day: 1}), r.byDay)
+	}
+
+	months := r.byMonth
+	if len(months) == 0 {
+		if len(r.byMonthDay) > 0 || len(r.byDay) > 0 {
+			months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		} else {
+			months = []int{int(s.dtstart.Month())}
+		}
+	}
+
+	arr := make([]date, 0)
+	for _, m := range sortedInts(months) {
+		arr = append(arr, s.datesOfMonth(year, time.Month(m))...)
+	}
+	return arr
+}
+
+func (s *Schedule) monthDates(year int, month time.Month) []date {
+	if len(s.rule.byMonth) > 0 && !contains(s.rule.byMonth, int(month)) {
+		return nil
+	}
+	return s.datesOfMonth(year, month)
+}
+
+func (s *Schedule) datesOfMonth(year int, month time.Month) []date {
+	r := s.rule
+	first := date{year: year, month: month, day: 1}
+	days := daysIn(year, month)
+
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		//months without day of dtstart are skipped
+		if s.dtstart.Day() > days {
+			return nil
+		}
+		return []date{{year: year, month: month, day: s.dtstart.Day()}}
+	}
+
+	var arr []date
+	if len(r.byMonthDay) > 0 {
+		arr = make([]date, 0)
+		for _, md := range r.byMonthDay {
+			d := md
+			if md < 0 {
+				d = days + md + 1
+			}
+			if d >= 1 && d <= days {
+				arr = append(arr, date{year: year, month: month, day: d})
+			}
+		}
+	}
+	if len(r.byDay) > 0 {
+		byDay := weekdaysIn(first, days, r.byDay)
+		if arr == nil {
+			arr = byDay
+		} else {
+			arr = intersect(arr, byDay)
+		}
+	}
+
+	sortDates(arr)
+	return unique(arr)
+}
+
+func (s *Schedule) weekStart(d date) date {
+	t := time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC)
+	shift := (int(t.Weekday()) - int(s.rule.wkst) + 7) % 7
+	return addDays(d, -shift)
+}
+
+func (s *Schedule) weekDates(start date) []date {
+	arr := make([]date, 0, 7)
+	for i := 0; i < 7; i++ {
+		d := addDays(start, i)
+		wd := weekday(d)
+		if len(s.rule.byDay) > 0 {
+			if !hasWeekday(s.rule.byDay, wd) {
+				continue
+			}
+		} else if wd != s.dtstart.Weekday() {
+			continue
+		}
+		if len(s.rule.byMonth) > 0 && !contains(s.rule.byMonth, int(d.month)) {
+			continue
+		}
+		arr = append(arr, d)
+	}
+	return arr
+}
+
+// filter dates by BYMONTH, BYMONTHDAY and BYDAY parts
+func (s *Schedule) filterDates(arr []date) []date {
+	r := s.rule
+	res := make([]date, 0, len(arr))
+	for _, d := range arr {
+		if len(r.byMonth) > 0 && !contains(r.byMonth, int(d.month)) {
+			continue
+		}
+		if len(r.byDay) > 0 && !hasWeekday(r.byDay, weekday(d)) {
+			continue
+		}
+		if len(r.byMonthDay) > 0 {
+			days := daysIn(d.year, d.month)
+			if !contains(r.byMonthDay, d.day) && !contains(r.byMonthDay, d.day-days-1) {
+				continue
+			}
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// times of day as hour, minute and second
+func (s *Schedule) timesOfDay() [][3]int {
+	r := s.rule
+	arr := make([][3]int, 0)
+	for _, h := range or(r.byHour, s.dtstart.Hour()) {
+		for _, m := range or(r.byMinute, s.dtstart.Minute()) {
+			for _, sec := range or(r.bySecond, s.dtstart.Second()) {
+				arr = append(arr, [3]int{h, m, sec})
+			}
+		}
+	}
+	return arr
+}
+
+func (s *Schedule) combine(dates []date, times [][3]int) []time.Time {
+	arr := make([]time.Time, 0, len(dates)*len(times))
+	for _, d := range dates {
+		for _, t := range times {
+			arr = append(arr, localTime(d.year, d.month, d.day, t[0], t[1], t[2], s.loc))
+		}
+	}
+	sortTimes(arr)
+	return arr
+}
+
+func (s *Schedule) applySetPos(arr []time.Time) []time.Time {
+	if len(s.rule.bySetPos) == 0 || len(arr) == 0 {
+		return arr
+	}
+	res := make([]time.Time, 0, len(s.rule.bySetPos))
+	for _, pos := range s.rule.bySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(arr) + pos
+		}
+		if i >= 0 && i < len(arr) {
+			res = append(res, arr[i])
+		}
+	}
+	sortTimes(res)
+	return res
+}
+
+// dates within n days starting at first matching weekdays, ordinals are counted within this range
+func weekdaysIn(first date, n int, byDay []weekdayNum) []date {
+	arr := make([]date, 0)
+	for _, wd := range byDay {
+		matched := make([]date, 0, 5)
+		//first day of range with required weekday
+		offset := (int(wd.day) - int(weekday(first)) + 7) % 7
+		for i := offset; i < n; i += 7 {
+			matched = append(matched, addDays(first, i))
+		}
+		switch {
+		case wd.n == 0:
+			arr = append(arr, matched...)
+		case wd.n > 0 && wd.n <= len(matched):
+			arr = append(arr, matched[wd.n-1])
+		case wd.n < 0 && -wd.n <= len(matched):
+			arr = append(arr, matched[len(matched)+wd.n])
+		}
+	}
+	sortDates(arr)
+	return unique(arr)
+}
+
+func hasWeekday(byDay []weekdayNum, day time.Weekday) bool {
+	for _, wd := range byDay {
+		if wd.day == day {
+			return true
+		}
+	}
+	return false
+}
+
+// local time of wall clock fields as RFC 5545 defines it: time within DST gap is shifted forward by length of gap,
+// ambiguous time within overlap is its first occurrence
+func localTime(year int, month time.Month, day, hour, minute, second int, loc *time.Location) time.Time {
+	instants, _ := utils.ResolveWallClock(time.Date(year, month, day, hour, minute, second, 0, time.UTC), loc)
+	return instants[0]
+}
+
+func weekday(d date) time.Weekday {
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, time.UTC).Weekday()
+}
+
+func addDays(d date, n int) date {
+	return dateOf(time.Date(d.year, d.month, d.day+n, 0, 0, 0, 0, time.UTC))
+}
+
+func daysBetween(from, to date) int {
+	a := time.Date(from.year, from.month, from.day, 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.year, to.month, to.day, 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a) / (24 * time.Hour))
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func or(arr []int, def int) []int {
+	if len(arr) > 0 {
+		return sortedInts(arr)
+	}
+	return []int{def}
+}
+
+func contains(arr []int, v int) bool {
+	for _, item := range arr {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedInts(arr []int) []int {
+	res := append([]int(nil), arr...)
+	sort.Ints(res)
+	return res
+}
+
+func sortTimes(arr []time.Time) {
+	sort.Slice(arr, func(i, j int) bool { return arr[i].Before(arr[j]) })
+}
+
+func sortDates(arr []date) {
+	sort.Slice(arr, func(i, j int) bool {
+		a, b := arr[i], arr[j]
+		if a.year != b.year {
+			return a.year < b.year
+		}
+		if a.month != b.month {
+			return a.month < b.month
+		}
+		return a.day < b.day
+	})
+}
+
+func unique(arr []date) []date {
+	res := arr[:0]
+	for i, d := range arr {
+		if i == 0 || d != arr[i-1] {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func intersect(a, b []date) []date {
+	res := make([]date, 0)
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				res = append(res, x)
+				break
+			}
+		}
+	}
+	return res
+}
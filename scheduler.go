@@ -2,35 +2,84 @@ package scheduler
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/executions"
 	"github.com/d1slike/go-sched/internal"
 	"github.com/d1slike/go-sched/jobs"
 	"github.com/d1slike/go-sched/stores"
 	"github.com/d1slike/go-sched/triggers"
+	"os"
 )
 
 type Option func(s *scheduler)
 
 type Scheduler interface {
 	Start()
+	// wait for running jobs until ctx is done, then cancel their contexts
 	Shutdown(ctx context.Context) error
-	RegisterExecutor(jType string, executor JobExecutor) Scheduler
+	RegisterExecutor(jType string, executor JobExecutor, opts ...ExecutorOption) Scheduler
 	UnregisterExecutor(jType string)
 	ScheduleJob(job jobs.MutableJob, trigger triggers.MutableTrigger) error
+	// add durable job without triggers
+	AddJob(job jobs.MutableJob) error
+	// add trigger to existing job
+	AddTrigger(jKey string, trigger triggers.MutableTrigger) error
+	// manual triggers created by TriggerJob are not listed
+	GetTriggersOfJob(jKey string) ([]triggers.ImmutableTrigger, error)
+	// replace schedule of existing trigger keeping its key and job, running execution is not affected
+	RescheduleTrigger(tKey string, trigger triggers.MutableTrigger, resetTriggeredTimes bool) error
 	GetJob(jKey string) (jobs.ImmutableJob, error)
 	GetTrigger(tKey string) (triggers.ImmutableTrigger, error)
 	DeleteJob(jKey string) (bool, error)
 	DeleteTrigger(tKey string) (bool, error)
 	GetJobs() ([]jobs.ImmutableJob, error)
+	// manual triggers created by TriggerJob are not listed
 	GetTriggers() ([]triggers.ImmutableTrigger, error)
 	UpdateJob(job jobs.MutableJob) error
+	// fire job once now by one-shot trigger, data is available by JobContext.UnmarshalTriggerData
+	TriggerJob(jKey string, data interface{}) error
+	PauseTrigger(tKey string) error
+	ResumeTrigger(tKey string) error
+	PauseJob(jKey string) error
+	ResumeJob(jKey string) error
+	// pause all existing triggers, triggers added after it are not paused
+	PauseAll() error
+	ResumeAll() error
+	WorkerPoolStats() PoolStats
+	// history of job executions, newest first. store must implement stores.ExecutionStore
+	GetExecutions(jKey string, filter executions.Filter) ([]executions.Execution, error)
+	GetExecution(id string) (*executions.Execution, error)
+	// cancel context of running executions, return false if nothing is running.
+	// executions running on other live clustered instances are interrupted if store implements stores.ExecutionStore
+	InterruptJob(jKey string) (bool, error)
+	InterruptExecution(id string) (bool, error)
+	// add calendar which could be referenced by triggers, triggers of replaced calendar are rescheduled by its new version
+	AddCalendar(name string, cal calendars.Calendar, replace bool) error
+	GetCalendar(name string) (calendars.Calendar, error)
+	GetCalendarNames() ([]string, error)
+	// calendar referenced by triggers could not be deleted
+	DeleteCalendar(name string) (bool, error)
 }
 
 type scheduler struct {
-	name     string
-	store    stores.Store
-	registry executorRegistry
-	executor executor
-	timers   Timers
+	name       string
+	instanceID string
+	store      stores.Store
+	registry   executorRegistry
+	listeners  *listenerRegistry
+	executor   executor
+	timers     Timers
+	misfire    triggers.MisfireInstruction
+	clustered  bool
+	clock      clock.Clock
+	poolSize   int
+	saturation SaturationPolicy
+
+	concurrentExecution ConcurrentExecutionPolicy
 }
 
 func (s *scheduler) GetJob(jKey string) (jobs.ImmutableJob, error) {
@@ -42,23 +91,40 @@ func (s *scheduler) GetTrigger(tKey string) (triggers.ImmutableTrigger, error) {
 }
 
 func (s *scheduler) DeleteJob(jKey string) (bool, error) {
-	ok, err := s.store.DeleteJob(s.name, jKey)
+	var (
+		ok   bool
+		keys []string
+	)
+	err := s.inTransaction(func(store stores.Store) (err error) {
+		ok, err = store.DeleteJob(s.name, jKey)
+		if err != nil || !ok {
+			return err
+		}
+		keys, err = store.DeleteTriggersByJobKey(s.name, jKey)
+		return err
+	})
 	if err != nil {
 		return false, err
 	}
-	if ok {
-		keys, err := s.store.DeleteTriggersByJobKey(s.name, jKey)
-		if err != nil {
-			return false, err
-		}
-		s.executor.CancelTriggers(keys...)
-	}
+	s.executor.CancelTriggers(keys...)
 
 	return ok, nil
 }
 
 func (s *scheduler) DeleteTrigger(tKey string) (bool, error) {
-	ok, err := s.store.DeleteTrigger(s.name, tKey)
+	var ok bool
+	err := s.inTransaction(func(store stores.Store) error {
+		t, err := store.GetTrigger(s.name, tKey)
+		if err != nil || t == nil {
+			return err
+		}
+
+		ok, err = store.DeleteTrigger(s.name, tKey)
+		if err != nil || !ok {
+			return err
+		}
+		return s.deleteOrphanedJob(store, t.JobKey())
+	})
 	if err != nil {
 		return false, err
 	}
@@ -68,12 +134,58 @@ func (s *scheduler) DeleteTrigger(tKey string) (bool, error) {
 	return ok, nil
 }
 
+// delete job which has no triggers anymore if it has opted in by jobs.MutableJob.DeleteWithLastTrigger
+func (s *scheduler) deleteOrphanedJob(store stores.Store, jKey string) error {
+	job, err := store.GetJob(s.name, jKey)
+	if err != nil || job == nil || job.Durable() || !job.DeletedWithLastTrigger() {
+		return err
+	}
+
+	arr, err := store.GetTriggersByJobKey(s.name, jKey)
+	if err != nil || len(arr) > 0 {
+		return err
+	}
+
+	_, err = store.DeleteJob(s.name, jKey)
+	return err
+}
+
+// run f atomically if store supports transactions
+func (s *scheduler) inTransaction(f func(store stores.Store) error) error {
+	if txStore, ok := s.store.(stores.TransactionalStore); ok {
+		return txStore.InTransaction(f)
+	}
+	return f(s.store)
+}
+
 func (s *scheduler) GetJobs() ([]jobs.ImmutableJob, error) {
 	return s.store.GetJobs(s.name)
 }
 
 func (s *scheduler) GetTriggers() ([]triggers.ImmutableTrigger, error) {
-	return s.store.GetTriggers(s.name)
+	arr, err := s.store.GetTriggers(s.name)
+	if err != nil {
+		return nil, err
+	}
+	return withoutManual(arr), nil
+}
+
+func (s *scheduler) GetTriggersOfJob(jKey string) ([]triggers.ImmutableTrigger, error) {
+	arr, err := s.store.GetTriggersByJobKey(s.name, jKey)
+	if err != nil {
+		return nil, err
+	}
+	return withoutManual(arr), nil
+}
+
+func withoutManual(arr []triggers.ImmutableTrigger) []triggers.ImmutableTrigger {
+	res := make([]triggers.ImmutableTrigger, 0, len(arr))
+	for _, t := range arr {
+		if !IsManualTrigger(t) {
+			res = append(res, t)
+		}
+	}
+	return res
 }
 
 func (s *scheduler) UnregisterExecutor(jType string) {
@@ -82,14 +194,16 @@ func (s *scheduler) UnregisterExecutor(jType string) {
 
 func (s *scheduler) Start() {
 	s.executor.Start()
+	s.listeners.schedulerStarted()
 }
 
 func (s *scheduler) Shutdown(ctx context.Context) error {
+	s.listeners.schedulerShuttingDown()
 	return s.executor.Shutdown(ctx)
 }
 
-func (s *scheduler) RegisterExecutor(jType string, executor JobExecutor) Scheduler {
-	s.registry.Register(jType, executor)
+func (s *scheduler) RegisterExecutor(jType string, executor JobExecutor, opts ...ExecutorOption) Scheduler {
+	s.registry.Register(jType, executor, opts...)
 	return s
 }
 
@@ -99,25 +213,132 @@ func (s *scheduler) ScheduleJob(job jobs.MutableJob, tri triggers.MutableTrigger
 		return err
 	}
 
-	t, err := tri.ToImmutable()
+	t, err := s.prepareTrigger(j.Key(), tri)
 	if err != nil {
 		return err
 	}
 
-	t = internal.ModifyTrigger(t, func(tr *internal.Trigger) {
-		tr.TjobKey = j.Key()
-		tr.Tstate = triggers.StateScheduled
+	return s.inTransaction(func(store stores.Store) error {
+		if err := store.InsertJob(s.name, j); err != nil {
+			return err
+		}
+		return store.InsertTrigger(s.name, t)
 	})
+}
 
-	if err := s.store.InsertJob(s.name, j); err != nil {
+func (s *scheduler) AddJob(job jobs.MutableJob) error {
+	j, err := job.ToImmutable()
+	if err != nil {
 		return err
 	}
+	if !j.Durable() {
+		return jobs.ErrNotDurable
+	}
 
-	if err := s.store.InsertTrigger(s.name, t); err != nil {
+	return s.store.InsertJob(s.name, j)
+}
+
+func (s *scheduler) AddTrigger(jKey string, tri triggers.MutableTrigger) error {
+	t, err := s.prepareTrigger(jKey, tri)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.insertTrigger(t)
+}
+
+// insert trigger of existing job
+func (s *scheduler) insertTrigger(t triggers.ImmutableTrigger) error {
+	return s.inTransaction(func(store stores.Store) error {
+		job, err := store.GetJob(s.name, t.JobKey())
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return stores.ErrJobNotFound
+		}
+		return store.InsertTrigger(s.name, t)
+	})
+}
+
+func (s *scheduler) RescheduleTrigger(tKey string, tri triggers.MutableTrigger, resetTriggeredTimes bool) error {
+	old, err := s.store.GetTrigger(s.name, tKey)
+	if err != nil {
+		return err
+	}
+	if old == nil {
+		return stores.ErrTriggerNotFound
+	}
+
+	t, err := s.prepareTrigger(old.JobKey(), tri.WithKey(tKey))
+	if err != nil {
+		return err
+	}
+
+	//running trigger stays acquired, executor calculates its next time by new schedule after execution
+	running := !s.executor.CancelPendingTrigger(tKey)
+	//trigger is read again in transaction, so concurrent changes made after canceling are not lost
+	return s.inTransaction(func(store stores.Store) error {
+		old, err := store.GetTrigger(s.name, tKey)
+		if err != nil {
+			return err
+		}
+		if old == nil {
+			return stores.ErrTriggerNotFound
+		}
+
+		t = internal.ModifyTrigger(t, func(tr *internal.Trigger) {
+			tr.TjobKey = old.JobKey()
+			if !resetTriggeredTimes {
+				tr.TtriggeredTime = old.TriggeredTimes()
+			}
+			switch {
+			case tr.Trepeats != triggers.RepeatInfinity && tr.TtriggeredTime >= tr.Trepeats:
+				tr.Tstate = triggers.StateExhausted
+			case old.State() == triggers.StatePaused:
+				tr.Tstate = triggers.StatePaused
+			case running && old.State() == triggers.StateAcquired:
+				tr.Tstate = triggers.StateAcquired
+				tr.TinstanceID = old.AcquiredBy()
+				tr.TacquiredAt = old.AcquiredAt()
+			}
+		})
+
+		return store.UpdateTrigger(s.name, t)
+	})
+}
+
+func (s *scheduler) prepareTrigger(jKey string, tri triggers.MutableTrigger) (triggers.ImmutableTrigger, error) {
+	t, err := internal.ToImmutableAt(tri, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var cal calendars.Calendar
+	if t.CalendarName() != "" {
+		store, err := calendarStore(s.store)
+		if err != nil {
+			return nil, err
+		}
+		if cal, err = store.GetCalendar(s.name, t.CalendarName()); err != nil {
+			return nil, err
+		}
+		if cal == nil {
+			return nil, stores.ErrCalendarNotFound
+		}
+	}
+
+	t = internal.ModifyTrigger(t, func(tr *internal.Trigger) {
+		tr.TjobKey = jKey
+		tr.Tstate = triggers.StateScheduled
+		tr.Tcal = cal
+		tr.TnextTime = internal.CalcNextTriggerTime(tr, s.clock.Now())
+	})
+	if t.NextTriggerTime().IsZero() {
+		return nil, triggers.ErrAlreadyExhausted
+	}
+
+	return t, nil
 }
 
 func (s *scheduler) UpdateJob(job jobs.MutableJob) error {
@@ -129,11 +350,344 @@ func (s *scheduler) UpdateJob(job jobs.MutableJob) error {
 	return s.store.UpdateJob(s.name, j)
 }
 
+func (s *scheduler) TriggerJob(jKey string, data interface{}) error {
+	t, err := newManualTrigger(jKey, data, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	return s.insertTrigger(t)
+}
+
+func (s *scheduler) WorkerPoolStats() PoolStats {
+	return s.executor.Stats()
+}
+
+func (s *scheduler) GetExecutions(jKey string, filter executions.Filter) ([]executions.Execution, error) {
+	history, ok := s.store.(stores.ExecutionStore)
+	if !ok {
+		return nil, executions.ErrNotSupported
+	}
+	return history.GetExecutions(s.name, jKey, filter)
+}
+
+func (s *scheduler) GetExecution(id string) (*executions.Execution, error) {
+	history, ok := s.store.(stores.ExecutionStore)
+	if !ok {
+		return nil, executions.ErrNotSupported
+	}
+	return history.GetExecution(s.name, id)
+}
+
+func (s *scheduler) InterruptJob(jKey string) (bool, error) {
+	interrupted := s.executor.InterruptJob(jKey) > 0
+
+	history, ok := s.store.(stores.ExecutionStore)
+	if !s.clustered || !ok {
+		return interrupted, nil
+	}
+
+	arr, err := history.GetExecutions(s.name, jKey, executions.Filter{Status: executions.StatusRunning})
+	if err != nil {
+		return interrupted, err
+	}
+	live, err := s.liveInstances()
+	if err != nil {
+		return interrupted, err
+	}
+	for _, execution := range arr {
+		if execution.InstanceID == s.instanceID || !live[execution.InstanceID] {
+			continue
+		}
+		ok, err := history.RequestInterrupt(s.name, execution.ID)
+		if err != nil {
+			return interrupted, err
+		}
+		interrupted = interrupted || ok
+	}
+
+	return interrupted, nil
+}
+
+func (s *scheduler) InterruptExecution(id string) (bool, error) {
+	if s.executor.InterruptExecution(id) {
+		return true, nil
+	}
+
+	history, ok := s.store.(stores.ExecutionStore)
+	if !s.clustered || !ok {
+		return false, nil
+	}
+
+	execution, err := history.GetExecution(s.name, id)
+	if err != nil || execution == nil || execution.InstanceID == s.instanceID {
+		return false, err
+	}
+	live, err := s.liveInstances()
+	if err != nil || !live[execution.InstanceID] {
+		return false, err
+	}
+
+	return history.RequestInterrupt(s.name, id)
+}
+
+// ids of instances which have reported heartbeat in time, executions of dead instances could not be interrupted
+func (s *scheduler) liveInstances() (map[string]bool, error) {
+	instances, err := s.store.GetInstances(s.name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	live := make(map[string]bool, len(instances))
+	for _, inst := range instances {
+		if now.Sub(inst.LastHeartbeat) < s.timers.ClusterInstanceTimeout {
+			live[inst.ID] = true
+		}
+	}
+
+	return live, nil
+}
+
+func (s *scheduler) AddCalendar(name string, cal calendars.Calendar, replace bool) error {
+	if name == "" {
+		return calendars.ErrEmptyCalendarName
+	}
+
+	err := s.inTransaction(func(tx stores.Store) error {
+		store, err := calendarStore(tx)
+		if err != nil {
+			return err
+		}
+		old, err := store.GetCalendar(s.name, name)
+		if err != nil {
+			return err
+		}
+		if old != nil && !replace {
+			return stores.ErrCalendarAlreadyExists
+		}
+		return store.SaveCalendar(s.name, name, cal)
+	})
+	if err != nil || !replace {
+		return err
+	}
+
+	return s.forEachTrigger(func(t triggers.ImmutableTrigger) bool {
+		return t.CalendarName() == name
+	}, func(t triggers.ImmutableTrigger) error {
+		return s.applyCalendar(t, cal)
+	})
+}
+
+func calendarStore(store stores.Store) (stores.CalendarStore, error) {
+	cals, ok := store.(stores.CalendarStore)
+	if !ok {
+		return nil, calendars.ErrNotSupported
+	}
+	return cals, nil
+}
+
+// recalculate next time of trigger by changed calendar, running trigger gets it from executor after execution
+func (s *scheduler) applyCalendar(t triggers.ImmutableTrigger, cal calendars.Calendar) error {
+	if t.State() == triggers.StateExhausted {
+		return nil
+	}
+	if t.State() == triggers.StateAcquired && !s.executor.CancelPendingTrigger(t.Key()) {
+		return nil
+	}
+
+	t = internal.ModifyTrigger(t, func(tr *internal.Trigger) {
+		tr.Tcal = cal
+		state := triggers.StateScheduled
+		if tr.Tstate == triggers.StatePaused {
+			state = triggers.StatePaused
+		}
+		if next := internal.CalcNextTriggerTime(tr, s.clock.Now()); next.IsZero() {
+			state = triggers.StateExhausted
+		} else {
+			tr.TnextTime = next
+		}
+		tr.Release(state)
+	})
+	if err := s.store.UpdateTrigger(s.name, t); err != nil {
+		return err
+	}
+	if t.State() == triggers.StateExhausted {
+		s.listeners.triggerExhausted(t)
+	}
+
+	return nil
+}
+
+func (s *scheduler) GetCalendar(name string) (calendars.Calendar, error) {
+	store, err := calendarStore(s.store)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetCalendar(s.name, name)
+}
+
+func (s *scheduler) GetCalendarNames() ([]string, error) {
+	store, err := calendarStore(s.store)
+	if err != nil {
+		return nil, err
+	}
+	return store.GetCalendarNames(s.name)
+}
+
+func (s *scheduler) DeleteCalendar(name string) (bool, error) {
+	var ok bool
+	err := s.inTransaction(func(tx stores.Store) error {
+		store, err := calendarStore(tx)
+		if err != nil {
+			return err
+		}
+		arr, err := tx.GetTriggers(s.name)
+		if err != nil {
+			return err
+		}
+		for _, t := range arr {
+			if t.CalendarName() == name {
+				return calendars.ErrCalendarInUse
+			}
+		}
+
+		ok, err = store.DeleteCalendar(s.name, name)
+		return err
+	})
+
+	return ok, err
+}
+
+func (s *scheduler) PauseTrigger(tKey string) error {
+	t, err := s.store.GetTrigger(s.name, tKey)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return stores.ErrTriggerNotFound
+	}
+	if t.State() == triggers.StateExhausted {
+		return triggers.ErrAlreadyExhausted
+	}
+
+	return s.pause(t)
+}
+
+func (s *scheduler) ResumeTrigger(tKey string) error {
+	t, err := s.store.GetTrigger(s.name, tKey)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return stores.ErrTriggerNotFound
+	}
+
+	return s.resume(t)
+}
+
+func (s *scheduler) PauseJob(jKey string) error {
+	return s.forEachTrigger(func(t triggers.ImmutableTrigger) bool {
+		return t.JobKey() == jKey
+	}, s.pause)
+}
+
+func (s *scheduler) ResumeJob(jKey string) error {
+	return s.forEachTrigger(func(t triggers.ImmutableTrigger) bool {
+		return t.JobKey() == jKey
+	}, s.resume)
+}
+
+func (s *scheduler) PauseAll() error {
+	return s.forEachTrigger(func(t triggers.ImmutableTrigger) bool {
+		return true
+	}, s.pause)
+}
+
+func (s *scheduler) ResumeAll() error {
+	return s.forEachTrigger(func(t triggers.ImmutableTrigger) bool {
+		return true
+	}, s.resume)
+}
+
+func (s *scheduler) forEachTrigger(
+	filter func(t triggers.ImmutableTrigger) bool,
+	f func(t triggers.ImmutableTrigger) error,
+) error {
+	//manual triggers just fire once, so they are not changed together with others
+	arr, err := s.GetTriggers()
+	if err != nil {
+		return err
+	}
+	for _, t := range arr {
+		if !filter(t) {
+			continue
+		}
+		if err := f(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scheduler) pause(t triggers.ImmutableTrigger) error {
+	if t.State() == triggers.StatePaused || t.State() == triggers.StateExhausted {
+		return nil
+	}
+
+	//only state is changed to not overwrite trigger updated concurrently by executor.
+	//owner of running trigger keeps it, so it could store result of execution
+	ok, err := s.store.UpdateTriggerState(
+		s.name,
+		t.Key(),
+		[]triggers.TriggerState{triggers.StateScheduled, triggers.StateAcquired},
+		triggers.StatePaused,
+	)
+	if err != nil || !ok {
+		return err
+	}
+	s.executor.CancelTriggers(t.Key())
+	s.listeners.triggerPaused(internal.ModifyTrigger(t, func(tr *internal.Trigger) {
+		tr.Tstate = triggers.StatePaused
+	}))
+
+	return nil
+}
+
+func (s *scheduler) resume(t triggers.ImmutableTrigger) error {
+	if t.State() != triggers.StatePaused {
+		return nil
+	}
+
+	//fire times missed while paused are handled by misfire instruction on next acquiring
+	ok, err := s.store.UpdateTriggerState(
+		s.name,
+		t.Key(),
+		[]triggers.TriggerState{triggers.StatePaused},
+		triggers.StateScheduled,
+	)
+	if err != nil || !ok {
+		return err
+	}
+	s.listeners.triggerResumed(internal.ModifyTrigger(t, func(tr *internal.Trigger) {
+		tr.Tstate = triggers.StateScheduled
+	}))
+
+	return nil
+}
+
 func NewScheduler(name string, opts ...Option) Scheduler {
 	s := &scheduler{
-		name:     name,
-		registry: newDefaultExecutorRegistry(),
-		timers:   NewDefaultTimers(),
+		name:       name,
+		instanceID: newInstanceID(),
+		registry:   newDefaultExecutorRegistry(),
+		listeners:  newListenerRegistry(),
+		timers:     NewDefaultTimers(),
+		misfire:    triggers.MisfireFireNow,
+		clock:      clock.New(),
+		saturation: SaturationBlock,
+
+		concurrentExecution: ConcurrentExecutionBlock,
 	}
 
 	for _, o := range opts {
@@ -145,10 +699,21 @@ func NewScheduler(name string, opts ...Option) Scheduler {
 	}
 
 	s.executor = newDefaultRuntimeExecutor(
-		s.name,
+		executorOptions{
+			sName:      s.name,
+			instanceID: s.instanceID,
+			clustered:  s.clustered,
+			timers:     s.timers,
+			misfire:    s.misfire,
+			clock:      s.clock,
+			poolSize:   s.poolSize,
+			saturation: s.saturation,
+
+			concurrentExecution: s.concurrentExecution,
+		},
 		s.store,
 		s.registry,
-		s.timers,
+		s.listeners,
 	)
 
 	return s
@@ -171,3 +736,86 @@ func WithTimers(timers Timers) Option {
 		s.timers = SetDefault(timers)
 	}
 }
+
+// stable instance id lets restarted process release triggers acquired before crash immediately
+func WithInstanceID(id string) Option {
+	return func(s *scheduler) {
+		s.instanceID = id
+	}
+}
+
+// how to treat triggers which have missed their fire time and have no own misfire instruction
+func WithMisfireInstruction(instruction triggers.MisfireInstruction) Option {
+	return func(s *scheduler) {
+		s.misfire = instruction
+	}
+}
+
+// alias of WithMisfireInstruction kept for configurations written before it, instruction also covers recovered triggers
+func WithRecoveryMisfireInstruction(instruction triggers.MisfireInstruction) Option {
+	return WithMisfireInstruction(instruction)
+}
+
+// scheduler instances sharing same store and name split triggers between each other
+// and take over triggers of dead instances
+func WithClustering() Option {
+	return func(s *scheduler) {
+		s.clustered = true
+	}
+}
+
+func WithJobListener(l JobListener) Option {
+	return func(s *scheduler) {
+		s.listeners.jobListeners = append(s.listeners.jobListeners, l)
+	}
+}
+
+func WithTriggerListener(l TriggerListener) Option {
+	return func(s *scheduler) {
+		s.listeners.triggerListeners = append(s.listeners.triggerListeners, l)
+	}
+}
+
+func WithSchedulerListener(l SchedulerListener) Option {
+	return func(s *scheduler) {
+		s.listeners.schedulerListeners = append(s.listeners.schedulerListeners, l)
+	}
+}
+
+// limit count of concurrently executed jobs, policy decides what to do with firing when all workers are busy
+func WithWorkerPool(size int, policy SaturationPolicy) Option {
+	return func(s *scheduler) {
+		s.poolSize = size
+		s.saturation = policy
+	}
+}
+
+// what to do with firing of job which disallows concurrent execution while previous one is running
+func WithConcurrentExecutionPolicy(policy ConcurrentExecutionPolicy) Option {
+	return func(s *scheduler) {
+		s.concurrentExecution = policy
+	}
+}
+
+// fake clock makes schedules testable without real waiting
+func WithClock(c clock.Clock) Option {
+	return func(s *scheduler) {
+		s.clock = c
+	}
+}
+
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+func newExecutionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestScheduler_SimpleTriggers(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second}
+
+	newScheduler := func(fake *clock.Fake, fired chan time.Time) Scheduler {
+		s := NewScheduler("simple", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().NextTriggerTime()
+			return nil
+		})
+		return s
+	}
+
+	//steal trigger and advance fake clock to its fire time
+	fire := func(s Scheduler, fake *clock.Fake, tKey string, fireTime time.Time) {
+		fake.BlockUntil(2)
+		fake.Advance(timers.TriggerStealTimeout)
+		So(waitFor(triggerAcquired(s, tKey)), ShouldBeTrue)
+		fake.BlockUntil(3)
+		fake.Advance(fireTime.Sub(fake.Now()))
+	}
+
+	Convey("Interval trigger must fire every interval starting at from time", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := newScheduler(fake, fired)
+		from := start.Add(30 * time.Second)
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithInterval(90*time.Second).WithFromTime(from).InLocation("UTC"),
+		), ShouldBeNil)
+
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.Kind(), ShouldEqual, triggers.KindInterval)
+		So(tr.Interval(), ShouldEqual, 90*time.Second)
+		So(tr.NextTriggerTime().Equal(from), ShouldBeTrue)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		for i := 0; i < 3; i++ {
+			fireTime := from.Add(time.Duration(i) * 90 * time.Second)
+			fire(s, fake, "t1", fireTime)
+			So((<-fired).Equal(fireTime), ShouldBeTrue)
+			So(waitFor(triggeredTimes(s, "t1", i+1)), ShouldBeTrue)
+		}
+	})
+
+	Convey("Interval trigger without from time must be anchored to scheduling time", t, func() {
+		fake := clock.NewFake(start)
+		s := newScheduler(fake, make(chan time.Time, 1))
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithInterval(time.Minute),
+		), ShouldBeNil)
+
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.FromTime().Equal(start), ShouldBeTrue)
+		So(tr.NextTriggerTime().Equal(start.Add(time.Minute)), ShouldBeTrue)
+
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithInterval(0)), ShouldEqual, triggers.ErrInvalidInterval)
+
+		//reused builder must anchor each trigger to its own scheduling time
+		builder := NewTrigger().WithInterval(time.Minute)
+		So(s.AddTrigger("j1", builder.WithKey("t3")), ShouldBeNil)
+		fake.Advance(10 * time.Second)
+		So(s.AddTrigger("j1", builder.WithKey("t4")), ShouldBeNil)
+		tr, err = s.GetTrigger("t4")
+		So(err, ShouldBeNil)
+		So(tr.FromTime().Equal(start.Add(10*time.Second)), ShouldBeTrue)
+	})
+
+	Convey("Recurrence rule trigger must fire by rule within repeats", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := newScheduler(fake, fired)
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithRRule("FREQ=MONTHLY;BYDAY=-1FR;BYHOUR=18;BYMINUTE=0;BYSECOND=0").
+				WithRepeats(triggers.Repeat(1)).InLocation("UTC"),
+		), ShouldBeNil)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithRRule("FREQ=MONTHLY;BYDAY=-1XX")), ShouldNotBeNil)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t3").WithRRule("")), ShouldEqual, triggers.ErrEmptyRRule)
+
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.Kind(), ShouldEqual, triggers.KindRRule)
+		So(tr.FromTime().Equal(start), ShouldBeTrue)
+		lastFriday := time.Date(2030, 1, 25, 18, 0, 0, 0, time.UTC)
+		So(tr.NextTriggerTime().Equal(lastFriday), ShouldBeTrue)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fire(s, fake, "t1", lastFriday)
+		So((<-fired).Equal(lastFriday), ShouldBeTrue)
+		So(waitFor(func() bool {
+			tr, err := s.GetTrigger("t1")
+			return err == nil && tr.State() == triggers.StateExhausted
+		}), ShouldBeTrue)
+	})
+
+	Convey("Daily interval trigger must fire within time window on weekdays", t, func() {
+		ny, err := time.LoadLocation("America/New_York")
+		So(err, ShouldBeNil)
+		//2030-01-04 is Friday
+		friday := time.Date(2030, 1, 4, 16, 50, 30, 0, ny)
+		fake := clock.NewFake(friday)
+		fired := make(chan time.Time, 1)
+		s := newScheduler(fake, fired)
+		workdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithDailyInterval("09:00", "17:00", 15*time.Minute, workdays...).
+				InLocation("America/New_York"),
+		), ShouldBeNil)
+
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithDailyInterval("17:00", "09:00", time.Minute)),
+			ShouldEqual, triggers.ErrInvalidTimeWindow)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithDailyInterval("9am", "17:00", time.Minute)), ShouldNotBeNil)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithDailyInterval("09:00", "17:00", 0)),
+			ShouldEqual, triggers.ErrInvalidInterval)
+
+		//wall clock time of window must be kept across DST transition on 2030-03-10
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t3").WithDailyInterval("09:00:00", "17:00:00", time.Hour, workdays...).
+			WithFromTime(time.Date(2030, 3, 8, 17, 0, 1, 0, ny)).InLocation("America/New_York")), ShouldBeNil)
+		tr, err := s.GetTrigger("t3")
+		So(err, ShouldBeNil)
+		So(tr.NextTriggerTime().Equal(time.Date(2030, 3, 11, 13, 0, 0, 0, time.UTC)), ShouldBeTrue)
+
+		tr, err = s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.Kind(), ShouldEqual, triggers.KindDailyInterval)
+		So(tr.DaysOfWeek(), ShouldResemble, workdays)
+		end := time.Date(2030, 1, 4, 17, 0, 0, 0, ny)
+		So(tr.NextTriggerTime().Equal(end), ShouldBeTrue)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fire(s, fake, "t1", end)
+		So((<-fired).Equal(end), ShouldBeTrue)
+		So(waitFor(func() bool {
+			tr, err := s.GetTrigger("t1")
+			return err == nil && tr.NextTriggerTime().Equal(time.Date(2030, 1, 7, 9, 0, 0, 0, ny))
+		}), ShouldBeTrue)
+	})
+
+	Convey("Extended cron trigger must fire by Quartz spec", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := newScheduler(fake, fired)
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithExtendedCron("15 0 10 ? * 6#1").InLocation("UTC"),
+		), ShouldBeNil)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").WithExtendedCron("")), ShouldEqual, triggers.ErrEmptyCronSpec)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t3").WithExtendedCron("0 0 10 32 * ?")).Error(), ShouldEqual,
+			"invalid cron spec: value 32 of day of month field is out of range 1-31 at position 8")
+		//standard cron trigger does not accept Quartz modifiers
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t4").WithCron("15 0 10 ? * 6#1")), ShouldNotBeNil)
+
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.Kind(), ShouldEqual, triggers.KindExtendedCron)
+		//first Friday of month
+		firstFriday := time.Date(2030, 1, 4, 10, 0, 15, 0, time.UTC)
+		So(tr.NextTriggerTime().Equal(firstFriday), ShouldBeTrue)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fire(s, fake, "t1", firstFriday)
+		So((<-fired).Equal(firstFriday), ShouldBeTrue)
+		So(waitFor(func() bool {
+			tr, err := s.GetTrigger("t1")
+			return err == nil && tr.NextTriggerTime().Equal(time.Date(2030, 2, 1, 10, 0, 15, 0, time.UTC))
+		}), ShouldBeTrue)
+	})
+
+	Convey("One-shot trigger must fire once", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan time.Time, 1)
+		s := newScheduler(fake, fired)
+		at := start.Add(time.Minute)
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").At(at),
+		), ShouldBeNil)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t2").After(2*time.Minute)), ShouldBeNil)
+		So(s.AddTrigger("j1", NewTrigger().WithKey("t3").After(0)), ShouldEqual, triggers.ErrEmptyFireTime)
+		delayed := NewTrigger().After(time.Minute)
+		So(s.AddTrigger("j1", delayed.WithKey("t4")), ShouldBeNil)
+		fake.Advance(10 * time.Second)
+		So(s.AddTrigger("j1", delayed.WithKey("t5")), ShouldBeNil)
+		tr, err := s.GetTrigger("t5")
+		So(err, ShouldBeNil)
+		So(tr.NextTriggerTime().Equal(start.Add(70*time.Second)), ShouldBeTrue)
+
+		tr, err = s.GetTrigger("t2")
+		So(err, ShouldBeNil)
+		So(tr.Kind(), ShouldEqual, triggers.KindOnce)
+		So(tr.NextTriggerTime().Equal(start.Add(2*time.Minute)), ShouldBeTrue)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		fire(s, fake, "t1", at)
+		So((<-fired).Equal(at), ShouldBeTrue)
+		So(waitFor(func() bool {
+			tr, err := s.GetTrigger("t1")
+			return err == nil && tr.State() == triggers.StateExhausted
+		}), ShouldBeTrue)
+	})
+}
@@ -0,0 +1,143 @@
+package stores
+
+import (
+	"github.com/d1slike/go-sched/executions"
+	"sort"
+	"time"
+)
+
+func (s *inMemoryStore) InsertExecution(sName string, execution executions.Execution) error {
+	defer s.shared()()
+
+	s.eLock.Lock()
+	defer s.eLock.Unlock()
+
+	s.eMap[storeKey(sName, execution.ID)] = execution
+
+	return nil
+}
+
+func (s *inMemoryStore) UpdateExecution(sName string, execution executions.Execution) error {
+	defer s.shared()()
+
+	s.eLock.Lock()
+	defer s.eLock.Unlock()
+
+	prev, ok := s.eMap[storeKey(sName, execution.ID)]
+	if !ok {
+		return ErrExecutionNotFound
+	}
+	execution.InterruptRequested = execution.InterruptRequested || prev.InterruptRequested
+	s.eMap[storeKey(sName, execution.ID)] = execution
+
+	return nil
+}
+
+func (s *inMemoryStore) GetExecution(sName string, id string) (*executions.Execution, error) {
+	defer s.shared()()
+
+	s.eLock.RLock()
+	defer s.eLock.RUnlock()
+
+	if execution, ok := s.eMap[storeKey(sName, id)]; ok {
+		return &execution, nil
+	}
+
+	return nil, nil
+}
+
+func (s *inMemoryStore) GetExecutions(
+	sName string,
+	jKey string,
+	filter executions.Filter,
+) ([]executions.Execution, error) {
+	defer s.shared()()
+
+	s.eLock.RLock()
+	defer s.eLock.RUnlock()
+
+	arr := make([]executions.Execution, 0)
+	for key, execution := range s.eMap {
+		if key.sName == sName && execution.JobKey == jKey && filter.Match(execution) {
+			arr = append(arr, execution)
+		}
+	}
+
+	sort.Slice(arr, func(i, j int) bool {
+		return arr[i].StartedAt.After(arr[j].StartedAt)
+	})
+	if filter.Limit > 0 && len(arr) > filter.Limit {
+		arr = arr[:filter.Limit]
+	}
+
+	return arr, nil
+}
+
+func (s *inMemoryStore) DeleteExecutions(sName string, finishedBefore time.Time) (int, error) {
+	defer s.shared()()
+
+	s.eLock.Lock()
+	defer s.eLock.Unlock()
+
+	deleted := 0
+	for key, execution := range s.eMap {
+		if key.sName == sName && execution.Status != executions.StatusRunning && execution.FinishedAt.Before(finishedBefore) {
+			delete(s.eMap, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *inMemoryStore) AbandonExecutions(sName string, instanceID string, finishedAt time.Time) (int, error) {
+	defer s.shared()()
+
+	s.eLock.Lock()
+	defer s.eLock.Unlock()
+
+	abandoned := 0
+	for key, execution := range s.eMap {
+		if key.sName == sName && execution.InstanceID == instanceID && execution.Status == executions.StatusRunning {
+			execution.Status = executions.StatusAbandoned
+			execution.FinishedAt = finishedAt
+			s.eMap[key] = execution
+			abandoned++
+		}
+	}
+
+	return abandoned, nil
+}
+
+func (s *inMemoryStore) RequestInterrupt(sName string, id string) (bool, error) {
+	defer s.shared()()
+
+	s.eLock.Lock()
+	defer s.eLock.Unlock()
+
+	execution, ok := s.eMap[storeKey(sName, id)]
+	if !ok || execution.Status != executions.StatusRunning {
+		return false, nil
+	}
+	execution.InterruptRequested = true
+	s.eMap[storeKey(sName, id)] = execution
+
+	return true, nil
+}
+
+func (s *inMemoryStore) GetInterruptRequests(sName string, instanceID string) ([]string, error) {
+	defer s.shared()()
+
+	s.eLock.RLock()
+	defer s.eLock.RUnlock()
+
+	ids := make([]string, 0)
+	for key, execution := range s.eMap {
+		if key.sName == sName && execution.InstanceID == instanceID &&
+			execution.Status == executions.StatusRunning && execution.InterruptRequested {
+			ids = append(ids, execution.ID)
+		}
+	}
+
+	return ids, nil
+}
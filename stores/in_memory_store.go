@@ -1,31 +1,61 @@
 package stores
 
 import (
-	"fmt"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/executions"
 	"github.com/d1slike/go-sched/internal"
 	"github.com/d1slike/go-sched/jobs"
 	"github.com/d1slike/go-sched/triggers"
-	"strings"
+	"sort"
 	"sync"
+	"time"
 )
 
 type inMemoryStore struct {
+	*inMemoryState
+	//view of store used by transaction which holds it exclusively
+	tx bool
+}
+
+type inMemoryState struct {
 	tLock sync.RWMutex
 	jLock sync.RWMutex
+	iLock sync.RWMutex
+	lLock sync.Mutex
+	eLock sync.RWMutex
+	cLock sync.RWMutex
+	//held exclusively by transaction and shared by other operations, so they do not see its uncommitted changes
+	txLock sync.RWMutex
+
+	tMap map[entityKey]triggers.ImmutableTrigger
+	jMap map[entityKey]jobs.ImmutableJob
+	iMap map[entityKey]time.Time
+	lMap map[entityKey]jobLock
+	eMap map[entityKey]executions.Execution
+	//calendars are kept serialized, so they could not be changed after saving
+	cMap map[entityKey][]byte
+}
 
-	tMap map[string]triggers.ImmutableTrigger
-	jMap map[string]jobs.ImmutableJob
+type entityKey struct {
+	sName string
+	key   string
+}
+
+type jobLock struct {
+	instanceID string
+	lockedAt   time.Time
 }
 
 func (s *inMemoryStore) DeleteTriggersByJobKey(sName string, jKey string) ([]string, error) {
+	defer s.shared()()
+
 	s.tLock.Lock()
 	defer s.tLock.Unlock()
 
 	arr := make([]string, 0)
-	newMap := make(map[string]triggers.ImmutableTrigger, len(s.tMap))
+	newMap := make(map[entityKey]triggers.ImmutableTrigger, len(s.tMap))
 	for key, trigger := range s.tMap {
-		scheduler, _ := splitStoreKey(key)
-		isOwner := scheduler == sName
+		isOwner := key.sName == sName
 		if (isOwner && trigger.JobKey() != jKey) || !isOwner {
 			newMap[key] = trigger
 		} else if isOwner {
@@ -34,11 +64,14 @@ func (s *inMemoryStore) DeleteTriggersByJobKey(sName string, jKey string) ([]str
 	}
 
 	s.tMap = newMap
+	sort.Strings(arr)
 
 	return arr, nil
 }
 
 func (s *inMemoryStore) InsertJob(sName string, job jobs.ImmutableJob) error {
+	defer s.shared()()
+
 	s.jLock.Lock()
 	defer s.jLock.Unlock()
 
@@ -52,6 +85,8 @@ func (s *inMemoryStore) InsertJob(sName string, job jobs.ImmutableJob) error {
 }
 
 func (s *inMemoryStore) InsertTrigger(sName string, trigger triggers.ImmutableTrigger) error {
+	defer s.shared()()
+
 	s.tLock.Lock()
 	defer s.tLock.Unlock()
 
@@ -65,6 +100,8 @@ func (s *inMemoryStore) InsertTrigger(sName string, trigger triggers.ImmutableTr
 }
 
 func (s *inMemoryStore) GetJob(sName string, jKey string) (jobs.ImmutableJob, error) {
+	defer s.shared()()
+
 	s.jLock.RLock()
 	defer s.jLock.RUnlock()
 
@@ -77,6 +114,8 @@ func (s *inMemoryStore) GetJob(sName string, jKey string) (jobs.ImmutableJob, er
 }
 
 func (s *inMemoryStore) GetTrigger(sName string, tKey string) (triggers.ImmutableTrigger, error) {
+	defer s.shared()()
+
 	s.tLock.RLock()
 	defer s.tLock.RUnlock()
 
@@ -89,6 +128,8 @@ func (s *inMemoryStore) GetTrigger(sName string, tKey string) (triggers.Immutabl
 }
 
 func (s *inMemoryStore) DeleteJob(sName string, jKey string) (bool, error) {
+	defer s.shared()()
+
 	s.jLock.Lock()
 	defer s.jLock.Unlock()
 
@@ -99,6 +140,8 @@ func (s *inMemoryStore) DeleteJob(sName string, jKey string) (bool, error) {
 }
 
 func (s *inMemoryStore) DeleteTrigger(sName string, tKey string) (bool, error) {
+	defer s.shared()()
+
 	s.tLock.Lock()
 	defer s.tLock.Unlock()
 
@@ -109,13 +152,14 @@ func (s *inMemoryStore) DeleteTrigger(sName string, tKey string) (bool, error) {
 }
 
 func (s *inMemoryStore) GetJobs(sName string) ([]jobs.ImmutableJob, error) {
+	defer s.shared()()
+
 	s.jLock.RLock()
 	defer s.jLock.RUnlock()
 
 	arr := make([]jobs.ImmutableJob, 0, len(s.jMap))
 	for key, job := range s.jMap {
-		scheduler, _ := splitStoreKey(key)
-		isOwner := scheduler == sName
+		isOwner := key.sName == sName
 		if isOwner {
 			arr = append(arr, job)
 		}
@@ -125,13 +169,14 @@ func (s *inMemoryStore) GetJobs(sName string) ([]jobs.ImmutableJob, error) {
 }
 
 func (s *inMemoryStore) GetTriggers(sName string) ([]triggers.ImmutableTrigger, error) {
+	defer s.shared()()
+
 	s.tLock.RLock()
 	defer s.tLock.RUnlock()
 
 	arr := make([]triggers.ImmutableTrigger, 0, len(s.jMap))
 	for key, trigger := range s.tMap {
-		scheduler, _ := splitStoreKey(key)
-		isOwner := scheduler == sName
+		isOwner := key.sName == sName
 		if isOwner {
 			arr = append(arr, trigger)
 		}
@@ -140,17 +185,112 @@ func (s *inMemoryStore) GetTriggers(sName string) ([]triggers.ImmutableTrigger,
 	return arr, nil
 }
 
-func (s *inMemoryStore) AcquireTriggers(sName string) ([]triggers.ImmutableTrigger, error) {
+func (s *inMemoryStore) GetTriggersByJobKey(sName string, jKey string) ([]triggers.ImmutableTrigger, error) {
+	defer s.shared()()
+
+	s.tLock.RLock()
+	defer s.tLock.RUnlock()
+
+	arr := make([]triggers.ImmutableTrigger, 0)
+	for key, trigger := range s.tMap {
+		if key.sName == sName && trigger.JobKey() == jKey {
+			arr = append(arr, trigger)
+		}
+	}
+
+	return arr, nil
+}
+
+func (s *inMemoryStore) AcquireTriggers(
+	sName string,
+	instanceID string,
+	now time.Time,
+	noLaterThan time.Time,
+	maxCount int,
+) ([]triggers.ImmutableTrigger, error) {
+	defer s.shared()()
+
+	s.tLock.Lock()
+	defer s.tLock.Unlock()
+
+	candidates := make([]entityKey, 0)
+	for key, trigger := range s.tMap {
+		isOwner := key.sName == sName
+		if !isOwner || trigger.State() != triggers.StateScheduled {
+			continue
+		}
+		if !noLaterThan.IsZero() && trigger.NextTriggerTime().After(noLaterThan) {
+			continue
+		}
+		candidates = append(candidates, key)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return s.tMap[candidates[i]].NextTriggerTime().Before(s.tMap[candidates[j]].NextTriggerTime())
+	})
+	if maxCount > 0 && len(candidates) > maxCount {
+		candidates = candidates[:maxCount]
+	}
+
+	arr := make([]triggers.ImmutableTrigger, 0, len(candidates))
+	for _, key := range candidates {
+		trigger := internal.ModifyTrigger(s.tMap[key], func(tr *internal.Trigger) {
+			tr.Tstate = triggers.StateAcquired
+			tr.TinstanceID = instanceID
+			tr.TacquiredAt = now
+		})
+		s.tMap[key] = trigger
+		arr = append(arr, trigger)
+	}
+
+	return arr, nil
+}
+
+func (s *inMemoryStore) RenewTriggers(sName string, instanceID string, tKeys []string, now time.Time) error {
+	defer s.shared()()
+
+	s.tLock.Lock()
+	defer s.tLock.Unlock()
+
+	for _, tKey := range tKeys {
+		key := storeKey(sName, tKey)
+		trigger, ok := s.tMap[key]
+		if ok && trigger.State() == triggers.StateAcquired && trigger.AcquiredBy() == instanceID {
+			s.tMap[key] = internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
+				tr.TacquiredAt = now
+			})
+		}
+	}
+
+	return nil
+}
+
+func (s *inMemoryStore) ReleaseTriggers(sName string, instanceID string) ([]triggers.ImmutableTrigger, error) {
+	defer s.shared()()
+
+	return s.release(sName, func(t triggers.ImmutableTrigger) bool {
+		return t.AcquiredBy() == instanceID
+	})
+}
+
+func (s *inMemoryStore) ReleaseStaleTriggers(sName string, acquiredBefore time.Time) ([]triggers.ImmutableTrigger, error) {
+	defer s.shared()()
+
+	return s.release(sName, func(t triggers.ImmutableTrigger) bool {
+		return t.AcquiredAt().Before(acquiredBefore)
+	})
+}
+
+func (s *inMemoryStore) release(sName string, match func(t triggers.ImmutableTrigger) bool) ([]triggers.ImmutableTrigger, error) {
 	s.tLock.Lock()
 	defer s.tLock.Unlock()
 
-	arr := make([]triggers.ImmutableTrigger, 0, len(s.jMap)/2)
+	arr := make([]triggers.ImmutableTrigger, 0)
 	for key, trigger := range s.tMap {
-		scheduler, _ := splitStoreKey(key)
-		isOwner := scheduler == sName
-		if isOwner && trigger.State() == triggers.StateScheduled {
+		isOwner := key.sName == sName
+		if isOwner && trigger.State() == triggers.StateAcquired && match(trigger) {
 			trigger = internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
-				tr.Tstate = triggers.StateAcquired
+				tr.Release(triggers.StateScheduled)
 			})
 			s.tMap[key] = trigger
 			arr = append(arr, trigger)
@@ -161,6 +301,8 @@ func (s *inMemoryStore) AcquireTriggers(sName string) ([]triggers.ImmutableTrigg
 }
 
 func (s *inMemoryStore) UpdateTrigger(sName string, trigger triggers.ImmutableTrigger) error {
+	defer s.shared()()
+
 	s.tLock.Lock()
 	defer s.tLock.Unlock()
 
@@ -174,7 +316,55 @@ func (s *inMemoryStore) UpdateTrigger(sName string, trigger triggers.ImmutableTr
 	return nil
 }
 
+func (s *inMemoryStore) UpdateAcquiredTrigger(sName string, instanceID string, trigger triggers.ImmutableTrigger) error {
+	defer s.shared()()
+
+	s.tLock.Lock()
+	defer s.tLock.Unlock()
+
+	old, ok := s.tMap[storeKey(sName, trigger.Key())]
+	if !ok {
+		return ErrTriggerNotFound
+	}
+	if old.AcquiredBy() != instanceID {
+		return ErrTriggerLeaseLost
+	}
+
+	s.tMap[storeKey(sName, trigger.Key())] = trigger
+
+	return nil
+}
+
+func (s *inMemoryStore) UpdateTriggerState(
+	sName string,
+	tKey string,
+	from []triggers.TriggerState,
+	to triggers.TriggerState,
+) (bool, error) {
+	defer s.shared()()
+
+	s.tLock.Lock()
+	defer s.tLock.Unlock()
+
+	trigger, ok := s.tMap[storeKey(sName, tKey)]
+	if !ok {
+		return false, nil
+	}
+	for _, state := range from {
+		if trigger.State() == state {
+			s.tMap[storeKey(sName, tKey)] = internal.ModifyTrigger(trigger, func(tr *internal.Trigger) {
+				tr.Tstate = to
+			})
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (s *inMemoryStore) UpdateJob(sName string, job jobs.ImmutableJob) error {
+	defer s.shared()()
+
 	s.jLock.Lock()
 	defer s.jLock.Unlock()
 
@@ -189,14 +379,15 @@ func (s *inMemoryStore) UpdateJob(sName string, job jobs.ImmutableJob) error {
 }
 
 func (s *inMemoryStore) DeleteExhaustedTriggers(sName string) (int, error) {
+	defer s.shared()()
+
 	s.tLock.Lock()
 	defer s.tLock.Unlock()
 
 	deleted := 0
-	newMap := make(map[string]triggers.ImmutableTrigger, len(s.tMap))
+	newMap := make(map[entityKey]triggers.ImmutableTrigger, len(s.tMap))
 	for key, trigger := range s.tMap {
-		scheduler, _ := splitStoreKey(key)
-		isOwner := scheduler == sName
+		isOwner := key.sName == sName
 		if (isOwner && trigger.State() != triggers.StateExhausted) || !isOwner {
 			newMap[key] = trigger
 		} else if isOwner {
@@ -209,18 +400,201 @@ func (s *inMemoryStore) DeleteExhaustedTriggers(sName string) (int, error) {
 	return deleted, nil
 }
 
-func NewInMemoryStore() Store {
-	return &inMemoryStore{
-		tMap: make(map[string]triggers.ImmutableTrigger),
-		jMap: make(map[string]jobs.ImmutableJob),
+func (s *inMemoryStore) Heartbeat(sName string, instanceID string, now time.Time) error {
+	defer s.shared()()
+
+	s.iLock.Lock()
+	defer s.iLock.Unlock()
+
+	s.iMap[storeKey(sName, instanceID)] = now
+
+	return nil
+}
+
+func (s *inMemoryStore) GetInstances(sName string) ([]Instance, error) {
+	defer s.shared()()
+
+	s.iLock.RLock()
+	defer s.iLock.RUnlock()
+
+	arr := make([]Instance, 0, len(s.iMap))
+	for key, heartbeat := range s.iMap {
+		if key.sName == sName {
+			arr = append(arr, Instance{ID: key.key, LastHeartbeat: heartbeat})
+		}
+	}
+
+	return arr, nil
+}
+
+func (s *inMemoryStore) DeleteInstance(sName string, instanceID string) (bool, error) {
+	defer s.shared()()
+
+	s.iLock.Lock()
+	defer s.iLock.Unlock()
+
+	_, ok := s.iMap[storeKey(sName, instanceID)]
+	delete(s.iMap, storeKey(sName, instanceID))
+
+	return ok, nil
+}
+
+func (s *inMemoryStore) LockJob(sName string, jKey string, instanceID string, now time.Time) (bool, error) {
+	defer s.shared()()
+
+	s.lLock.Lock()
+	defer s.lLock.Unlock()
+
+	if _, locked := s.lMap[storeKey(sName, jKey)]; locked {
+		return false, nil
+	}
+	s.lMap[storeKey(sName, jKey)] = jobLock{instanceID: instanceID, lockedAt: now}
+
+	return true, nil
+}
+
+func (s *inMemoryStore) UnlockJob(sName string, jKey string, instanceID string) error {
+	defer s.shared()()
+
+	s.lLock.Lock()
+	defer s.lLock.Unlock()
+
+	if lock, ok := s.lMap[storeKey(sName, jKey)]; ok && lock.instanceID == instanceID {
+		delete(s.lMap, storeKey(sName, jKey))
+	}
+
+	return nil
+}
+
+func (s *inMemoryStore) ReleaseJobLocks(sName string, instanceID string) (int, error) {
+	defer s.shared()()
+
+	s.lLock.Lock()
+	defer s.lLock.Unlock()
+
+	released := 0
+	for key, lock := range s.lMap {
+		if key.sName == sName && lock.instanceID == instanceID {
+			delete(s.lMap, key)
+			released++
+		}
+	}
+
+	return released, nil
+}
+
+func (s *inMemoryStore) RenewJobLocks(sName string, instanceID string, now time.Time) error {
+	defer s.shared()()
+
+	s.lLock.Lock()
+	defer s.lLock.Unlock()
+
+	for key, lock := range s.lMap {
+		if key.sName == sName && lock.instanceID == instanceID {
+			lock.lockedAt = now
+			s.lMap[key] = lock
+		}
 	}
+
+	return nil
+}
+
+func (s *inMemoryStore) ReleaseStaleJobLocks(sName string, lockedBefore time.Time) (int, error) {
+	defer s.shared()()
+
+	s.lLock.Lock()
+	defer s.lLock.Unlock()
+
+	released := 0
+	for key, lock := range s.lMap {
+		if key.sName == sName && lock.lockedAt.Before(lockedBefore) {
+			delete(s.lMap, key)
+			released++
+		}
+	}
+
+	return released, nil
+}
+
+func (s *inMemoryStore) SaveCalendar(sName string, name string, cal calendars.Calendar) error {
+	defer s.shared()()
+
+	b, err := calendars.Marshal(cal)
+	if err != nil {
+		return err
+	}
+
+	s.cLock.Lock()
+	defer s.cLock.Unlock()
+
+	s.cMap[storeKey(sName, name)] = b
+
+	return nil
+}
+
+func (s *inMemoryStore) GetCalendar(sName string, name string) (calendars.Calendar, error) {
+	defer s.shared()()
+
+	s.cLock.RLock()
+	defer s.cLock.RUnlock()
+
+	b, ok := s.cMap[storeKey(sName, name)]
+	if !ok {
+		return nil, nil
+	}
+
+	return calendars.Unmarshal(b)
+}
+
+func (s *inMemoryStore) GetCalendarNames(sName string) ([]string, error) {
+	defer s.shared()()
+
+	s.cLock.RLock()
+	defer s.cLock.RUnlock()
+
+	arr := make([]string, 0)
+	for key := range s.cMap {
+		if key.sName == sName {
+			arr = append(arr, key.key)
+		}
+	}
+	sort.Strings(arr)
+
+	return arr, nil
 }
 
-func storeKey(sName, entityKey string) string {
-	return fmt.Sprintf("%s_%s", sName, entityKey)
+func (s *inMemoryStore) DeleteCalendar(sName string, name string) (bool, error) {
+	defer s.shared()()
+
+	s.cLock.Lock()
+	defer s.cLock.Unlock()
+
+	_, ok := s.cMap[storeKey(sName, name)]
+	delete(s.cMap, storeKey(sName, name))
+
+	return ok, nil
+}
+
+// hold store shared unless it is used by transaction holding it exclusively
+func (s *inMemoryStore) shared() func() {
+	if s.tx {
+		return func() {}
+	}
+	s.txLock.RLock()
+	return s.txLock.RUnlock
+}
+
+func NewInMemoryStore() Store {
+	return &inMemoryStore{inMemoryState: &inMemoryState{
+		tMap: make(map[entityKey]triggers.ImmutableTrigger),
+		jMap: make(map[entityKey]jobs.ImmutableJob),
+		iMap: make(map[entityKey]time.Time),
+		lMap: make(map[entityKey]jobLock),
+		eMap: make(map[entityKey]executions.Execution),
+		cMap: make(map[entityKey][]byte),
+	}}
 }
 
-func splitStoreKey(key string) (sName string, entityKey string) {
-	parts := strings.SplitN(key, "_", 2)
-	return parts[0], parts[1]
+func storeKey(sName, key string) entityKey {
+	return entityKey{sName: sName, key: key}
 }
@@ -1,14 +1,18 @@
 package stores
 
 import (
+	"errors"
+	"github.com/d1slike/go-sched/executions"
 	"github.com/d1slike/go-sched/internal"
 	"github.com/d1slike/go-sched/triggers"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
+	"time"
 )
 
 const (
-	sName = "test"
+	sName      = "test"
+	instanceID = "instance"
 )
 
 func TestInMemoryStore_Insert(t *testing.T) {
@@ -269,7 +273,7 @@ func TestInMemoryStore_AcquireTriggers(t *testing.T) {
 		})
 
 		Convey("must return 2 triggers", func() {
-			arr, err := store.AcquireTriggers(sName)
+			arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
 			So(err, ShouldBeNil)
 			So(arr, ShouldHaveLength, 2)
 			checkStatus := true
@@ -288,7 +292,7 @@ func TestInMemoryStore_AcquireTriggers(t *testing.T) {
 		})
 
 		Convey("must return empty array", func() {
-			arr, err := store.AcquireTriggers(sName)
+			arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
 			So(err, ShouldBeNil)
 			So(arr, ShouldBeEmpty)
 		})
@@ -323,3 +327,71 @@ func TestInMemoryStore_DeleteTriggersByJobKey(t *testing.T) {
 		})
 	})
 }
+
+func TestInMemoryStore_TransactionIsolation(t *testing.T) {
+	Convey("Test transaction isolation", t, func() {
+		store := NewInMemoryStore()
+		txStore := store.(TransactionalStore)
+		So(store.InsertJob(sName, &internal.Job{Jkey: "job1", JjType: "type1"}), ShouldBeNil)
+		So(store.InsertTrigger(sName, &internal.Trigger{Tkey: "t1", TjobKey: "job1", Tstate: triggers.StateScheduled}), ShouldBeNil)
+
+		Convey("must hide changes of transaction and keep concurrent ones after rollback", func() {
+			rollback := errors.New("rollback")
+			inTx := make(chan struct{})
+			done := make(chan error, 1)
+			go func() {
+				done <- txStore.InTransaction(func(tx Store) error {
+					if err := tx.InsertJob(sName, &internal.Job{Jkey: "job2", JjType: "type1"}); err != nil {
+						return err
+					}
+					if err := tx.UpdateTrigger(sName, &internal.Trigger{Tkey: "t1", TjobKey: "job1", Tstate: triggers.StatePaused}); err != nil {
+						return err
+					}
+					close(inTx)
+					time.Sleep(50 * time.Millisecond)
+					return rollback
+				})
+			}()
+			<-inTx
+
+			//blocks until transaction is rolled back
+			err := store.UpdateTrigger(sName, &internal.Trigger{Tkey: "t1", TjobKey: "job1", Tstate: triggers.StateExhausted})
+			So(err, ShouldBeNil)
+			So(<-done, ShouldEqual, rollback)
+
+			j, err := store.GetJob(sName, "job2")
+			So(err, ShouldBeNil)
+			So(j, ShouldBeNil)
+
+			t, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(t.State(), ShouldEqual, triggers.StateExhausted)
+		})
+
+		Convey("must hold execution history until transaction is finished", func() {
+			history := store.(ExecutionStore)
+			inTx := make(chan struct{})
+			release := make(chan struct{})
+			done := make(chan error, 1)
+			go func() {
+				done <- txStore.InTransaction(func(tx Store) error {
+					close(inTx)
+					<-release
+					return nil
+				})
+			}()
+			<-inTx
+
+			inserted := make(chan error, 1)
+			go func() {
+				inserted <- history.InsertExecution(sName, executions.Execution{ID: "e1", JobKey: "job1"})
+			}()
+			time.Sleep(20 * time.Millisecond)
+			So(inserted, ShouldBeEmpty)
+
+			close(release)
+			So(<-done, ShouldBeNil)
+			So(<-inserted, ShouldBeNil)
+		})
+	})
+}
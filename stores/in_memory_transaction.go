@@ -0,0 +1,285 @@
+package stores
+
+import (
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/jobs"
+	"github.com/d1slike/go-sched/triggers"
+	"time"
+)
+
+// store passed to transaction function, remembers how to restore jobs, triggers, job locks and calendars changed by it.
+// instances and execution history are changed by embedded store without rollback.
+// transaction holds store exclusively until it ends, so its changes are not visible to others before commit
+// and rollback could not overwrite concurrent changes
+type inMemoryTx struct {
+	*inMemoryStore
+	undo []func()
+}
+
+func (s *inMemoryStore) InTransaction(f func(tx Store) error) error {
+	s.txLock.Lock()
+	defer s.txLock.Unlock()
+
+	tx := &inMemoryTx{inMemoryStore: &inMemoryStore{inMemoryState: s.inMemoryState, tx: true}}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.rollback()
+		}
+	}()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
+
+func (tx *inMemoryTx) InsertJob(sName string, job jobs.ImmutableJob) error {
+	return tx.changeJob(sName, job.Key(), func() error {
+		return tx.inMemoryStore.InsertJob(sName, job)
+	})
+}
+
+func (tx *inMemoryTx) UpdateJob(sName string, job jobs.ImmutableJob) error {
+	return tx.changeJob(sName, job.Key(), func() error {
+		return tx.inMemoryStore.UpdateJob(sName, job)
+	})
+}
+
+func (tx *inMemoryTx) DeleteJob(sName string, jKey string) (bool, error) {
+	var ok bool
+	err := tx.changeJob(sName, jKey, func() (err error) {
+		ok, err = tx.inMemoryStore.DeleteJob(sName, jKey)
+		return err
+	})
+	return ok, err
+}
+
+func (tx *inMemoryTx) InsertTrigger(sName string, trigger triggers.ImmutableTrigger) error {
+	return tx.changeTrigger(sName, trigger.Key(), func() error {
+		return tx.inMemoryStore.InsertTrigger(sName, trigger)
+	})
+}
+
+func (tx *inMemoryTx) UpdateTrigger(sName string, trigger triggers.ImmutableTrigger) error {
+	return tx.changeTrigger(sName, trigger.Key(), func() error {
+		return tx.inMemoryStore.UpdateTrigger(sName, trigger)
+	})
+}
+
+func (tx *inMemoryTx) UpdateAcquiredTrigger(sName string, instanceID string, trigger triggers.ImmutableTrigger) error {
+	return tx.changeTrigger(sName, trigger.Key(), func() error {
+		return tx.inMemoryStore.UpdateAcquiredTrigger(sName, instanceID, trigger)
+	})
+}
+
+func (tx *inMemoryTx) UpdateTriggerState(
+	sName string,
+	tKey string,
+	from []triggers.TriggerState,
+	to triggers.TriggerState,
+) (bool, error) {
+	var ok bool
+	err := tx.changeTrigger(sName, tKey, func() (err error) {
+		ok, err = tx.inMemoryStore.UpdateTriggerState(sName, tKey, from, to)
+		return err
+	})
+	return ok, err
+}
+
+func (tx *inMemoryTx) DeleteTrigger(sName string, tKey string) (bool, error) {
+	var ok bool
+	err := tx.changeTrigger(sName, tKey, func() (err error) {
+		ok, err = tx.inMemoryStore.DeleteTrigger(sName, tKey)
+		return err
+	})
+	return ok, err
+}
+
+func (tx *inMemoryTx) DeleteTriggersByJobKey(sName string, jKey string) ([]string, error) {
+	prev, err := tx.inMemoryStore.GetTriggersByJobKey(sName, jKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := tx.inMemoryStore.DeleteTriggersByJobKey(sName, jKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range prev {
+		tx.undo = append(tx.undo, tx.restoreTrigger(storeKey(sName, t.Key()), t))
+	}
+
+	return keys, nil
+}
+
+func (tx *inMemoryTx) LockJob(sName string, jKey string, instanceID string, now time.Time) (bool, error) {
+	var ok bool
+	err := tx.changeJobLocks(sName, func() (err error) {
+		ok, err = tx.inMemoryStore.LockJob(sName, jKey, instanceID, now)
+		return err
+	})
+	return ok, err
+}
+
+func (tx *inMemoryTx) UnlockJob(sName string, jKey string, instanceID string) error {
+	return tx.changeJobLocks(sName, func() error {
+		return tx.inMemoryStore.UnlockJob(sName, jKey, instanceID)
+	})
+}
+
+func (tx *inMemoryTx) ReleaseJobLocks(sName string, instanceID string) (int, error) {
+	var n int
+	err := tx.changeJobLocks(sName, func() (err error) {
+		n, err = tx.inMemoryStore.ReleaseJobLocks(sName, instanceID)
+		return err
+	})
+	return n, err
+}
+
+func (tx *inMemoryTx) RenewJobLocks(sName string, instanceID string, now time.Time) error {
+	return tx.changeJobLocks(sName, func() error {
+		return tx.inMemoryStore.RenewJobLocks(sName, instanceID, now)
+	})
+}
+
+func (tx *inMemoryTx) ReleaseStaleJobLocks(sName string, lockedBefore time.Time) (int, error) {
+	var n int
+	err := tx.changeJobLocks(sName, func() (err error) {
+		n, err = tx.inMemoryStore.ReleaseStaleJobLocks(sName, lockedBefore)
+		return err
+	})
+	return n, err
+}
+
+func (tx *inMemoryTx) SaveCalendar(sName string, name string, cal calendars.Calendar) error {
+	return tx.changeCalendar(sName, name, func() error {
+		return tx.inMemoryStore.SaveCalendar(sName, name, cal)
+	})
+}
+
+func (tx *inMemoryTx) DeleteCalendar(sName string, name string) (bool, error) {
+	var ok bool
+	err := tx.changeCalendar(sName, name, func() (err error) {
+		ok, err = tx.inMemoryStore.DeleteCalendar(sName, name)
+		return err
+	})
+	return ok, err
+}
+
+func (tx *inMemoryTx) changeJob(sName string, jKey string, f func() error) error {
+	prev, err := tx.inMemoryStore.GetJob(sName, jKey)
+	if err != nil {
+		return err
+	}
+	if err := f(); err != nil {
+		return err
+	}
+
+	key := storeKey(sName, jKey)
+	tx.undo = append(tx.undo, func() {
+		tx.jLock.Lock()
+		defer tx.jLock.Unlock()
+		if prev == nil {
+			delete(tx.jMap, key)
+		} else {
+			tx.jMap[key] = prev
+		}
+	})
+
+	return nil
+}
+
+func (tx *inMemoryTx) changeTrigger(sName string, tKey string, f func() error) error {
+	prev, err := tx.inMemoryStore.GetTrigger(sName, tKey)
+	if err != nil {
+		return err
+	}
+	if err := f(); err != nil {
+		return err
+	}
+
+	tx.undo = append(tx.undo, tx.restoreTrigger(storeKey(sName, tKey), prev))
+
+	return nil
+}
+
+// operations on job locks could touch several of them, so all locks of scheduler are restored
+func (tx *inMemoryTx) changeJobLocks(sName string, f func() error) error {
+	tx.lLock.Lock()
+	prev := make(map[entityKey]jobLock)
+	for key, lock := range tx.lMap {
+		if key.sName == sName {
+			prev[key] = lock
+		}
+	}
+	tx.lLock.Unlock()
+
+	if err := f(); err != nil {
+		return err
+	}
+
+	tx.undo = append(tx.undo, func() {
+		tx.lLock.Lock()
+		defer tx.lLock.Unlock()
+		for key := range tx.lMap {
+			if key.sName == sName {
+				delete(tx.lMap, key)
+			}
+		}
+		for key, lock := range prev {
+			tx.lMap[key] = lock
+		}
+	})
+
+	return nil
+}
+
+func (tx *inMemoryTx) changeCalendar(sName string, name string, f func() error) error {
+	key := storeKey(sName, name)
+	tx.cLock.RLock()
+	prev, existed := tx.cMap[key]
+	tx.cLock.RUnlock()
+
+	if err := f(); err != nil {
+		return err
+	}
+
+	tx.undo = append(tx.undo, func() {
+		tx.cLock.Lock()
+		defer tx.cLock.Unlock()
+		if existed {
+			tx.cMap[key] = prev
+		} else {
+			delete(tx.cMap, key)
+		}
+	})
+
+	return nil
+}
+
+func (tx *inMemoryTx) restoreTrigger(key entityKey, prev triggers.ImmutableTrigger) func() {
+	return func() {
+		tx.tLock.Lock()
+		defer tx.tLock.Unlock()
+		if prev == nil {
+			delete(tx.tMap, key)
+		} else {
+			tx.tMap[key] = prev
+		}
+	}
+}
+
+// undo changes in reverse order
+func (tx *inMemoryTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+}
+
+func (tx *inMemoryTx) InTransaction(f func(tx Store) error) error {
+	//nested transaction is part of outer one
+	return f(tx)
+}
@@ -0,0 +1,181 @@
+package stores
+
+import (
+	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/jobs"
+	"github.com/d1slike/go-sched/retry"
+	"github.com/d1slike/go-sched/triggers"
+	"time"
+)
+
+// persisted fields of job, lets stores outside of this module save and restore jobs
+type JobRecord struct {
+	Key                    string
+	Type                   string
+	Data                   []byte
+	Timeout                time.Duration
+	RetryPolicy            *retry.Policy
+	ConcurrentDisallowed   bool
+	Durable                bool
+	DeletedWithLastTrigger bool
+}
+
+// persisted fields of trigger, lets stores outside of this module save and restore triggers
+type TriggerRecord struct {
+	Key          string
+	JobKey       string
+	Kind         triggers.ScheduleKind
+	CronSpec     string
+	Interval     time.Duration
+	RRule        string
+	DayStart     string
+	DayEnd       string
+	DaysOfWeek   []time.Weekday
+	FromTime     *time.Time
+	ToTime       *time.Time
+	Repeats      triggers.Repeats
+	Location     string
+	DSTGap       triggers.DSTGapPolicy
+	DSTOverlap   triggers.DSTOverlapPolicy
+	CalendarName string
+	Data         []byte
+	Timeout      time.Duration
+	RetryPolicy  *retry.Policy
+	Misfire      triggers.MisfireInstruction
+	Manual       bool
+
+	State          triggers.TriggerState
+	TriggeredTimes triggers.Repeats
+	NextTime       time.Time
+	AcquiredBy     string
+	AcquiredAt     time.Time
+	FailedAttempts int
+	LastError      string
+}
+
+func NewJobRecord(j jobs.ImmutableJob) JobRecord {
+	return JobRecord{
+		Key:                    j.Key(),
+		Type:                   j.Type(),
+		Data:                   j.Data(),
+		Timeout:                j.Timeout(),
+		RetryPolicy:            j.RetryPolicy(),
+		ConcurrentDisallowed:   j.ConcurrentExecutionDisallowed(),
+		Durable:                j.Durable(),
+		DeletedWithLastTrigger: j.DeletedWithLastTrigger(),
+	}
+}
+
+// job which could be returned by store
+func (r JobRecord) Restore() jobs.ImmutableJob {
+	return &internal.Job{
+		Jkey:            r.Key,
+		JjType:          r.Type,
+		Jdata:           r.Data,
+		Jtimeout:        r.Timeout,
+		Jretry:          r.RetryPolicy,
+		Jexclusive:      r.ConcurrentDisallowed,
+		Jdurable:        r.Durable,
+		JdeleteOrphaned: r.DeletedWithLastTrigger,
+	}
+}
+
+func NewTriggerRecord(t triggers.ImmutableTrigger) TriggerRecord {
+	start, end := t.DailyWindow()
+	gap, overlap := t.DSTPolicy()
+	return TriggerRecord{
+		Key:            t.Key(),
+		JobKey:         t.JobKey(),
+		Kind:           t.Kind(),
+		CronSpec:       t.CronSpec(),
+		Interval:       t.Interval(),
+		RRule:          t.RRule(),
+		DayStart:       start,
+		DayEnd:         end,
+		DaysOfWeek:     t.DaysOfWeek(),
+		FromTime:       t.FromTime(),
+		ToTime:         t.ToTime(),
+		Repeats:        t.Repeats(),
+		Location:       locationName(t),
+		DSTGap:         gap,
+		DSTOverlap:     overlap,
+		CalendarName:   t.CalendarName(),
+		Data:           t.Data(),
+		Timeout:        t.Timeout(),
+		RetryPolicy:    t.RetryPolicy(),
+		Misfire:        t.MisfireInstruction(),
+		Manual:         t.Manual(),
+		State:          t.State(),
+		TriggeredTimes: t.TriggeredTimes(),
+		NextTime:       t.NextTriggerTime(),
+		AcquiredBy:     t.AcquiredBy(),
+		AcquiredAt:     t.AcquiredAt(),
+		FailedAttempts: t.FailedAttempts(),
+		LastError:      t.LastError(),
+	}
+}
+
+// trigger with resolved location and schedule which could be returned by store
+func (r TriggerRecord) Restore() (triggers.ImmutableTrigger, error) {
+	t := internal.NewTrigger()
+	t.Tkey = r.Key
+	t.TjobKey = r.JobKey
+	t.Tkind = r.Kind
+	t.TcronSpec = r.CronSpec
+	t.Tinterval = r.Interval
+	t.Trrule = r.RRule
+	t.TdayStart = r.DayStart
+	t.TdayEnd = r.DayEnd
+	t.Tdays = r.DaysOfWeek
+	t.TfromTime = r.FromTime
+	t.Trepeats = r.Repeats
+	t.Tlocation = r.Location
+	t.Tgap = r.DSTGap
+	t.Toverlap = r.DSTOverlap
+	t.Tcalendar = r.CalendarName
+	t.Tdata = r.Data
+	t.Ttimeout = r.Timeout
+	t.Tretry = r.RetryPolicy
+	t.Tmisfire = r.Misfire
+	t.Tmanual = r.Manual
+	t.Tstate = r.State
+	t.TtriggeredTime = r.TriggeredTimes
+	t.TinstanceID = r.AcquiredBy
+	t.TacquiredAt = r.AcquiredAt
+	t.TfailedCount = r.FailedAttempts
+	t.TlastError = r.LastError
+	if t.Tlocation == "" {
+		t.Tlocation = "Local"
+	}
+
+	//schedule could be anchored to from time
+	if err := t.Restore(); err != nil {
+		return nil, err
+	}
+
+	t.TfromTime = inLocation(r.FromTime, t.Tloc)
+	t.TtoTime = inLocation(r.ToTime, t.Tloc)
+	if !r.NextTime.IsZero() {
+		t.TnextTime = r.NextTime.In(t.Tloc)
+	}
+
+	return t, nil
+}
+
+func locationName(t triggers.ImmutableTrigger) string {
+	if tr, ok := t.(*internal.Trigger); ok && tr.Tlocation != "" {
+		return tr.Tlocation
+	}
+	if t.Location() != nil {
+		return t.Location().String()
+	}
+	return "Local"
+}
+
+func inLocation(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.In(loc)
+	return &v
+}
@@ -0,0 +1,104 @@
+package stores
+
+import (
+	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/retry"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestRecord_Restore(t *testing.T) {
+	Convey("Test restoring of records", t, func() {
+		Convey("job must be restored from its record", func() {
+			policy := retry.Fixed(3, time.Second)
+			job := &internal.Job{Jkey: "j1", JjType: "type", Jdata: []byte("data"), Jretry: &policy, Jexclusive: true}
+			So(NewJobRecord(job).Restore(), ShouldResemble, job)
+		})
+
+		Convey("trigger must keep its fields", func() {
+			from := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+			r := TriggerRecord{
+				Key:            "t1",
+				JobKey:         "j1",
+				Kind:           triggers.KindInterval,
+				Interval:       90 * time.Second,
+				FromTime:       &from,
+				Repeats:        triggers.RepeatInfinity,
+				Location:       "Europe/Moscow",
+				State:          triggers.StateAcquired,
+				NextTime:       from.Add(90 * time.Second),
+				AcquiredBy:     instanceID,
+				AcquiredAt:     from,
+				FailedAttempts: 1,
+				LastError:      "failed",
+			}
+			tr, err := r.Restore()
+			So(err, ShouldBeNil)
+			So(tr.Location().String(), ShouldEqual, "Europe/Moscow")
+			So(tr.FromTime().Location().String(), ShouldEqual, "Europe/Moscow")
+
+			restored := NewTriggerRecord(tr)
+			So(restored.FromTime.Equal(from), ShouldBeTrue)
+			So(restored.NextTime.Equal(r.NextTime), ShouldBeTrue)
+			restored.FromTime, restored.NextTime = r.FromTime, r.NextTime
+			So(restored, ShouldResemble, r)
+		})
+
+		Convey("must return err if location is invalid", func() {
+			_, err := TriggerRecord{Key: "t1", CronSpec: "0 0 * * * *", Location: "Unknown/Location"}.Restore()
+			So(err, ShouldNotBeNil)
+		})
+
+		from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+		cases := []struct {
+			name     string
+			record   TriggerRecord
+			after    time.Time
+			expected time.Time
+		}{
+			{
+				name:     "interval",
+				record:   TriggerRecord{Kind: triggers.KindInterval, Interval: 90 * time.Second, FromTime: &from},
+				after:    from,
+				expected: from.Add(90 * time.Second),
+			},
+			{
+				name:     "recurrence rule",
+				record:   TriggerRecord{Kind: triggers.KindRRule, RRule: "RRULE:FREQ=MONTHLY;BYDAY=-1FR\nEXDATE:20300125T000000Z", FromTime: &from},
+				after:    from,
+				expected: time.Date(2030, 2, 22, 0, 0, 0, 0, time.UTC),
+			},
+			{
+				//2030-01-01 is Tuesday
+				name: "daily interval",
+				record: TriggerRecord{
+					Kind:       triggers.KindDailyInterval,
+					DayStart:   "09:00",
+					DayEnd:     "17:00",
+					Interval:   30 * time.Minute,
+					DaysOfWeek: []time.Weekday{time.Monday, time.Friday},
+				},
+				after:    from,
+				expected: time.Date(2030, 1, 4, 9, 0, 0, 0, time.UTC),
+			},
+			{
+				name:     "extended cron",
+				record:   TriggerRecord{Kind: triggers.KindExtendedCron, CronSpec: "0 0 18 LW * ? 2030"},
+				after:    time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC),
+				expected: time.Date(2030, 3, 29, 18, 0, 0, 0, time.UTC),
+			},
+		}
+		for _, c := range cases {
+			Convey("must restore schedule of "+c.name+" trigger", func() {
+				c.record.Key = "t1"
+				c.record.Location = "UTC"
+				tr, err := c.record.Restore()
+				So(err, ShouldBeNil)
+				next := internal.CalcNextTriggerTime(tr.(*internal.Trigger), c.after)
+				So(next.Equal(c.expected), ShouldBeTrue)
+			})
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package stores
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+const (
+	jobsTable       = "sched_jobs"
+	triggersTable   = "sched_triggers"
+	instancesTable  = "sched_instances"
+	jobLocksTable   = "sched_job_locks"
+	executionsTable = "sched_executions"
+	calendarsTable  = "sched_calendars"
+)
+
+var (
+	SQLiteDialect   SQLDialect = &sqliteDialect{}
+	PostgresDialect SQLDialect = &postgresDialect{}
+)
+
+type SQLDialect interface {
+	Name() string
+	Placeholder(index int) string
+	// row locking clause for select used in trigger acquiring
+	LockClause() string
+	Schema() []string
+}
+
+type sqliteDialect struct {
+}
+
+func (d *sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (d *sqliteDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (d *sqliteDialect) LockClause() string {
+	//sqlite locks whole database on write
+	return ""
+}
+
+func (d *sqliteDialect) Schema() []string {
+	return schema("BLOB")
+}
+
+type postgresDialect struct {
+}
+
+func (d *postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (d *postgresDialect) Placeholder(index int) string {
+	return fmt.Sprintf("$%d", index)
+}
+
+func (d *postgresDialect) LockClause() string {
+	return "FOR UPDATE SKIP LOCKED"
+}
+
+func (d *postgresDialect) Schema() []string {
+	return schema("BYTEA")
+}
+
+func schema(blobType string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + jobsTable + ` (
+	sched_name          VARCHAR(200) NOT NULL,
+	job_key             VARCHAR(200) NOT NULL,
+	job_type            VARCHAR(200) NOT NULL,
+	job_data            ` + blobType + `,
+	job_timeout         BIGINT       NOT NULL DEFAULT 0,
+	job_retry           TEXT,
+	job_exclusive       BOOLEAN      NOT NULL DEFAULT FALSE,
+	job_durable         BOOLEAN      NOT NULL DEFAULT FALSE,
+	job_delete_orphaned BOOLEAN      NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (sched_name, job_key)
+)`,
+		`CREATE TABLE IF NOT EXISTS ` + triggersTable + ` (
+	sched_name      VARCHAR(200) NOT NULL,
+	trigger_key     VARCHAR(200) NOT NULL,
+	job_key         VARCHAR(200) NOT NULL,
+	from_time       BIGINT,
+	to_time         BIGINT,
+	repeats         INTEGER      NOT NULL,
+	cron_spec       VARCHAR(200) NOT NULL,
+	location        VARCHAR(100) NOT NULL,
+	trigger_data    ` + blobType + `,
+	state           VARCHAR(20)  NOT NULL,
+	triggered_times INTEGER      NOT NULL,
+	next_time       BIGINT,
+	instance_id     VARCHAR(200) NOT NULL DEFAULT '',
+	acquired_at     BIGINT,
+	timeout         BIGINT       NOT NULL DEFAULT 0,
+	retry_policy    TEXT,
+	misfire         VARCHAR(20)  NOT NULL DEFAULT '',
+	failed_attempts INTEGER      NOT NULL DEFAULT 0,
+	last_error      TEXT         NOT NULL DEFAULT '',
+	schedule_kind   VARCHAR(20)  NOT NULL DEFAULT 'CRON',
+	repeat_interval BIGINT       NOT NULL DEFAULT 0,
+	recurrence_rule TEXT         NOT NULL DEFAULT '',
+	calendar_name   VARCHAR(200) NOT NULL DEFAULT '',
+	day_start       VARCHAR(8)   NOT NULL DEFAULT '',
+	day_end         VARCHAR(8)   NOT NULL DEFAULT '',
+	days_of_week    VARCHAR(20)  NOT NULL DEFAULT '',
+	dst_gap         VARCHAR(20)  NOT NULL DEFAULT '',
+	dst_overlap     VARCHAR(20)  NOT NULL DEFAULT '',
+	manual          BOOLEAN      NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (sched_name, trigger_key)
+)`,
+		`CREATE INDEX IF NOT EXISTS idx_sched_triggers_state ON ` + triggersTable + ` (sched_name, state)`,
+		`CREATE INDEX IF NOT EXISTS idx_sched_triggers_job ON ` + triggersTable + ` (sched_name, job_key)`,
+		`CREATE TABLE IF NOT EXISTS ` + instancesTable + ` (
+	sched_name     VARCHAR(200) NOT NULL,
+	instance_id    VARCHAR(200) NOT NULL,
+	last_heartbeat BIGINT       NOT NULL,
+	PRIMARY KEY (sched_name, instance_id)
+)`,
+		`CREATE TABLE IF NOT EXISTS ` + jobLocksTable + ` (
+	sched_name  VARCHAR(200) NOT NULL,
+	job_key     VARCHAR(200) NOT NULL,
+	instance_id VARCHAR(200) NOT NULL,
+	locked_at   BIGINT       NOT NULL,
+	PRIMARY KEY (sched_name, job_key)
+)`,
+		`CREATE TABLE IF NOT EXISTS ` + executionsTable + ` (
+	sched_name          VARCHAR(200) NOT NULL,
+	execution_id        VARCHAR(64)  NOT NULL,
+	job_key             VARCHAR(200) NOT NULL,
+	trigger_key         VARCHAR(200) NOT NULL,
+	instance_id         VARCHAR(200) NOT NULL,
+	attempt             INTEGER      NOT NULL,
+	started_at          BIGINT       NOT NULL,
+	finished_at         BIGINT,
+	status              VARCHAR(20)  NOT NULL,
+	error               TEXT         NOT NULL DEFAULT '',
+	result              ` + blobType + `,
+	interrupt_requested BOOLEAN      NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (sched_name, execution_id)
+)`,
+		`CREATE INDEX IF NOT EXISTS idx_sched_executions_job ON ` + executionsTable + ` (sched_name, job_key, started_at)`,
+		`CREATE TABLE IF NOT EXISTS ` + calendarsTable + ` (
+	sched_name    VARCHAR(200) NOT NULL,
+	calendar_name VARCHAR(200) NOT NULL,
+	calendar_data TEXT         NOT NULL,
+	PRIMARY KEY (sched_name, calendar_name)
+)`,
+	}
+}
+
+func CreateSQLSchema(db *sql.DB, dialect SQLDialect) error {
+	for _, stmt := range dialect.Schema() {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s schema: %v", dialect.Name(), err)
+		}
+	}
+	return nil
+}
+
+// replace '?' placeholders with dialect specific ones
+func rebind(dialect SQLDialect, query string) string {
+	var b strings.Builder
+	index := 0
+	for _, r := range query {
+		if r == '?' {
+			index++
+			b.WriteString(dialect.Placeholder(index))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,217 @@
+package stores
+
+import (
+	"database/sql"
+	"github.com/d1slike/go-sched/executions"
+	"time"
+)
+
+const (
+	executionColumns = "execution_id, job_key, trigger_key, instance_id, attempt, started_at, finished_at, status, error, result, interrupt_requested"
+)
+
+func (s *sqlStore) InsertExecution(sName string, execution executions.Execution) error {
+	_, err := s.db.Exec(
+		s.query(`INSERT INTO `+executionsTable+` (sched_name, `+executionColumns+`) `+
+			`VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		sName,
+		execution.ID,
+		execution.JobKey,
+		execution.TriggerKey,
+		execution.InstanceID,
+		execution.Attempt,
+		execution.StartedAt.UnixNano(),
+		toNullTime(&execution.FinishedAt),
+		string(execution.Status),
+		execution.Error,
+		execution.Result,
+		execution.InterruptRequested,
+	)
+
+	return err
+}
+
+func (s *sqlStore) UpdateExecution(sName string, execution executions.Execution) error {
+	res, err := s.db.Exec(
+		s.query(`UPDATE `+executionsTable+` SET finished_at = ?, status = ?, error = ?, result = ? `+
+			`WHERE sched_name = ? AND execution_id = ?`),
+		toNullTime(&execution.FinishedAt),
+		string(execution.Status),
+		execution.Error,
+		execution.Result,
+		sName,
+		execution.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrExecutionNotFound
+	}
+
+	return nil
+}
+
+func (s *sqlStore) GetExecution(sName string, id string) (*executions.Execution, error) {
+	row := s.db.QueryRow(
+		s.query(`SELECT `+executionColumns+` FROM `+executionsTable+` WHERE sched_name = ? AND execution_id = ?`),
+		sName, id,
+	)
+
+	execution, err := scanExecution(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &execution, nil
+}
+
+func (s *sqlStore) GetExecutions(
+	sName string,
+	jKey string,
+	filter executions.Filter,
+) ([]executions.Execution, error) {
+	q := `SELECT ` + executionColumns + ` FROM ` + executionsTable + ` WHERE sched_name = ? AND job_key = ?`
+	args := []interface{}{sName, jKey}
+	if filter.Status != "" {
+		q += ` AND status = ?`
+		args = append(args, string(filter.Status))
+	}
+	if !filter.StartedFrom.IsZero() {
+		q += ` AND started_at >= ?`
+		args = append(args, filter.StartedFrom.UnixNano())
+	}
+	if !filter.StartedTo.IsZero() {
+		q += ` AND started_at < ?`
+		args = append(args, filter.StartedTo.UnixNano())
+	}
+	q += ` ORDER BY started_at DESC`
+	if filter.Limit > 0 {
+		q += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(s.query(q), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arr := make([]executions.Execution, 0)
+	for rows.Next() {
+		execution, err := scanExecution(rows)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, execution)
+	}
+
+	return arr, rows.Err()
+}
+
+func (s *sqlStore) DeleteExecutions(sName string, finishedBefore time.Time) (int, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+executionsTable+` WHERE sched_name = ? AND status <> ? AND finished_at < ?`),
+		sName, string(executions.StatusRunning), finishedBefore.UnixNano(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (s *sqlStore) AbandonExecutions(sName string, instanceID string, finishedAt time.Time) (int, error) {
+	res, err := s.db.Exec(
+		s.query(`UPDATE `+executionsTable+` SET finished_at = ?, status = ? `+
+			`WHERE sched_name = ? AND instance_id = ? AND status = ?`),
+		finishedAt.UnixNano(), string(executions.StatusAbandoned), sName, instanceID, string(executions.StatusRunning),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (s *sqlStore) RequestInterrupt(sName string, id string) (bool, error) {
+	res, err := s.db.Exec(
+		s.query(`UPDATE `+executionsTable+` SET interrupt_requested = ? `+
+			`WHERE sched_name = ? AND execution_id = ? AND status = ?`),
+		true, sName, id, string(executions.StatusRunning),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *sqlStore) GetInterruptRequests(sName string, instanceID string) ([]string, error) {
+	rows, err := s.db.Query(
+		s.query(`SELECT execution_id FROM `+executionsTable+` `+
+			`WHERE sched_name = ? AND instance_id = ? AND status = ? AND interrupt_requested = ?`),
+		sName, instanceID, string(executions.StatusRunning), true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func scanExecution(row rowScanner) (executions.Execution, error) {
+	var (
+		execution  executions.Execution
+		startedAt  int64
+		finishedAt sql.NullInt64
+		status     string
+	)
+
+	err := row.Scan(
+		&execution.ID, &execution.JobKey, &execution.TriggerKey, &execution.InstanceID, &execution.Attempt,
+		&startedAt, &finishedAt, &status, &execution.Error, &execution.Result, &execution.InterruptRequested,
+	)
+	if err != nil {
+		return execution, err
+	}
+
+	execution.StartedAt = time.Unix(0, startedAt)
+	if t := fromNullTime(finishedAt, time.Local); t != nil {
+		execution.FinishedAt = *t
+	}
+	execution.Status = executions.Status(status)
+
+	return execution, nil
+}
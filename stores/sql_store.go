@@ -0,0 +1,877 @@
+package stores
+
+import (
+	"database/sql"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/jobs"
+	"github.com/d1slike/go-sched/json"
+	"github.com/d1slike/go-sched/retry"
+	"github.com/d1slike/go-sched/triggers"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	jobColumns     = "job_key, job_type, job_data, job_timeout, job_retry, job_exclusive, job_durable, job_delete_orphaned"
+	triggerColumns = "trigger_key, job_key, from_time, to_time, repeats, cron_spec, location, trigger_data, " +
+		"state, triggered_times, next_time, instance_id, acquired_at, timeout, " +
+		"retry_policy, misfire, failed_attempts, last_error, schedule_kind, repeat_interval, recurrence_rule, calendar_name, day_start, day_end, days_of_week, dst_gap, dst_overlap, manual"
+	//keys listed in one query, older SQLite builds allow at most 999 parameters
+	maxKeysPerQuery = 500
+)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// *sql.DB or *sql.Tx of running transaction
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type sqlStore struct {
+	db      sqlExecutor
+	dialect SQLDialect
+}
+
+func (s *sqlStore) InsertJob(sName string, job jobs.ImmutableJob) error {
+	retryPolicy, err := marshalRetryPolicy(job.RetryPolicy())
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.Exec(
+		s.query(`INSERT INTO `+jobsTable+` (sched_name, `+jobColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`),
+		sName, job.Key(), job.Type(), job.Data(), int64(job.Timeout()), retryPolicy, job.ConcurrentExecutionDisallowed(),
+		job.Durable(), job.DeletedWithLastTrigger(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrJobAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *sqlStore) InsertTrigger(sName string, trigger triggers.ImmutableTrigger) error {
+	tArgs, err := triggerArgs(trigger)
+	if err != nil {
+		return err
+	}
+
+	args := append([]interface{}{sName}, tArgs...)
+	res, err := s.db.Exec(
+		s.query(`INSERT INTO `+triggersTable+` (sched_name, `+triggerColumns+`) `+
+			`VALUES (`+inPlaceholders(len(args))+`) ON CONFLICT DO NOTHING`),
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrTriggerAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *sqlStore) GetJob(sName string, jKey string) (jobs.ImmutableJob, error) {
+	row := s.db.QueryRow(
+		s.query(`SELECT `+jobColumns+` FROM `+jobsTable+` WHERE sched_name = ? AND job_key = ?`),
+		sName, jKey,
+	)
+
+	j, err := scanJob(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+func (s *sqlStore) GetTrigger(sName string, tKey string) (triggers.ImmutableTrigger, error) {
+	row := s.db.QueryRow(
+		s.query(`SELECT `+triggerColumns+` FROM `+triggersTable+` WHERE sched_name = ? AND trigger_key = ?`),
+		sName, tKey,
+	)
+
+	t, err := scanTrigger(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *sqlStore) DeleteJob(sName string, jKey string) (bool, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+jobsTable+` WHERE sched_name = ? AND job_key = ?`),
+		sName, jKey,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *sqlStore) DeleteTrigger(sName string, tKey string) (bool, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+triggersTable+` WHERE sched_name = ? AND trigger_key = ?`),
+		sName, tKey,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *sqlStore) DeleteTriggersByJobKey(sName string, jKey string) ([]string, error) {
+	var arr []string
+	err := s.transact(func(tx *sqlStore) error {
+		rows, err := tx.db.Query(
+			s.query(`SELECT trigger_key FROM `+triggersTable+` WHERE sched_name = ? AND job_key = ? ORDER BY trigger_key`),
+			sName, jKey,
+		)
+		if err != nil {
+			return err
+		}
+
+		arr = make([]string, 0)
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return err
+			}
+			arr = append(arr, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		_, err = tx.db.Exec(
+			s.query(`DELETE FROM `+triggersTable+` WHERE sched_name = ? AND job_key = ?`),
+			sName, jKey,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return arr, nil
+}
+
+func (s *sqlStore) InTransaction(f func(tx Store) error) error {
+	return s.transact(func(tx *sqlStore) error {
+		return f(tx)
+	})
+}
+
+func (s *sqlStore) transact(f func(tx *sqlStore) error) error {
+	db, ok := s.db.(*sql.DB)
+	if !ok {
+		//nested transaction is part of outer one
+		return f(s)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(&sqlStore{db: tx, dialect: s.dialect}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *sqlStore) GetJobs(sName string) ([]jobs.ImmutableJob, error) {
+	rows, err := s.db.Query(
+		s.query(`SELECT `+jobColumns+` FROM `+jobsTable+` WHERE sched_name = ?`),
+		sName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arr := make([]jobs.ImmutableJob, 0)
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, j)
+	}
+
+	return arr, rows.Err()
+}
+
+func (s *sqlStore) GetTriggers(sName string) ([]triggers.ImmutableTrigger, error) {
+	rows, err := s.db.Query(
+		s.query(`SELECT `+triggerColumns+` FROM `+triggersTable+` WHERE sched_name = ?`),
+		sName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTriggers(rows)
+}
+
+func (s *sqlStore) GetTriggersByJobKey(sName string, jKey string) ([]triggers.ImmutableTrigger, error) {
+	rows, err := s.db.Query(
+		s.query(`SELECT `+triggerColumns+` FROM `+triggersTable+` WHERE sched_name = ? AND job_key = ?`),
+		sName, jKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTriggers(rows)
+}
+
+func (s *sqlStore) AcquireTriggers(
+	sName string,
+	instanceID string,
+	now time.Time,
+	noLaterThan time.Time,
+	maxCount int,
+) ([]triggers.ImmutableTrigger, error) {
+	args := []interface{}{triggers.StateAcquired, instanceID, now.UnixNano(), sName, triggers.StateScheduled}
+	args = append(args, sName, triggers.StateScheduled)
+
+	candidates := `SELECT trigger_key FROM ` + triggersTable + ` WHERE sched_name = ? AND state = ?`
+	if !noLaterThan.IsZero() {
+		candidates += ` AND next_time <= ?`
+		args = append(args, noLaterThan.UnixNano())
+	}
+	candidates += ` ORDER BY next_time`
+	if maxCount > 0 {
+		candidates += ` LIMIT ?`
+		args = append(args, maxCount)
+	}
+	if lock := s.dialect.LockClause(); lock != "" {
+		candidates += ` ` + lock
+	}
+
+	rows, err := s.db.Query(
+		s.query(`UPDATE `+triggersTable+` SET state = ?, instance_id = ?, acquired_at = ? `+
+			`WHERE sched_name = ? AND state = ? AND trigger_key IN (`+candidates+`) RETURNING `+triggerColumns),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTriggers(rows)
+}
+
+func (s *sqlStore) RenewTriggers(sName string, instanceID string, tKeys []string, now time.Time) error {
+	//keys are sent by chunks to not exceed limit of query parameters
+	for start := 0; start < len(tKeys); start += maxKeysPerQuery {
+		end := start + maxKeysPerQuery
+		if end > len(tKeys) {
+			end = len(tKeys)
+		}
+		chunk := tKeys[start:end]
+
+		args := []interface{}{now.UnixNano(), sName, instanceID, triggers.StateAcquired}
+		for _, key := range chunk {
+			args = append(args, key)
+		}
+		_, err := s.db.Exec(
+			s.query(`UPDATE `+triggersTable+` SET acquired_at = ? `+
+				`WHERE sched_name = ? AND instance_id = ? AND state = ? AND trigger_key IN (`+inPlaceholders(len(chunk))+`)`),
+			args...,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlStore) ReleaseTriggers(sName string, instanceID string) ([]triggers.ImmutableTrigger, error) {
+	rows, err := s.db.Query(
+		s.query(`UPDATE `+triggersTable+` SET state = ?, instance_id = '', acquired_at = NULL `+
+			`WHERE sched_name = ? AND state = ? AND instance_id = ? RETURNING `+triggerColumns),
+		triggers.StateScheduled, sName, triggers.StateAcquired, instanceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTriggers(rows)
+}
+
+func (s *sqlStore) ReleaseStaleTriggers(sName string, acquiredBefore time.Time) ([]triggers.ImmutableTrigger, error) {
+	rows, err := s.db.Query(
+		s.query(`UPDATE `+triggersTable+` SET state = ?, instance_id = '', acquired_at = NULL `+
+			`WHERE sched_name = ? AND state = ? AND (acquired_at IS NULL OR acquired_at < ?) RETURNING `+triggerColumns),
+		triggers.StateScheduled, sName, triggers.StateAcquired, acquiredBefore.UnixNano(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanTriggers(rows)
+}
+
+func (s *sqlStore) UpdateTrigger(sName string, trigger triggers.ImmutableTrigger) error {
+	n, err := s.updateTrigger(sName, trigger, ``)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTriggerNotFound
+	}
+
+	return nil
+}
+
+func (s *sqlStore) UpdateAcquiredTrigger(sName string, instanceID string, trigger triggers.ImmutableTrigger) error {
+	n, err := s.updateTrigger(sName, trigger, ` AND instance_id = ?`, instanceID)
+	if err != nil || n > 0 {
+		return err
+	}
+
+	if old, err := s.GetTrigger(sName, trigger.Key()); err != nil {
+		return err
+	} else if old == nil {
+		return ErrTriggerNotFound
+	}
+
+	return ErrTriggerLeaseLost
+}
+
+func (s *sqlStore) UpdateTriggerState(
+	sName string,
+	tKey string,
+	from []triggers.TriggerState,
+	to triggers.TriggerState,
+) (bool, error) {
+	if len(from) == 0 {
+		return false, nil
+	}
+
+	args := []interface{}{to, sName, tKey}
+	for _, state := range from {
+		args = append(args, state)
+	}
+	res, err := s.db.Exec(
+		s.query(`UPDATE `+triggersTable+` SET state = ? `+
+			`WHERE sched_name = ? AND trigger_key = ? AND state IN (`+inPlaceholders(len(from))+`)`),
+		args...,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// update trigger matching extra condition, return number of updated rows
+func (s *sqlStore) updateTrigger(
+	sName string,
+	trigger triggers.ImmutableTrigger,
+	cond string,
+	condArgs ...interface{},
+) (int64, error) {
+	tArgs, err := triggerArgs(trigger)
+	if err != nil {
+		return 0, err
+	}
+
+	args := append(tArgs[1:], sName, trigger.Key())
+	args = append(args, condArgs...)
+	res, err := s.db.Exec(
+		s.query(`UPDATE `+triggersTable+` SET job_key = ?, from_time = ?, to_time = ?, repeats = ?, cron_spec = ?, `+
+			`location = ?, trigger_data = ?, state = ?, triggered_times = ?, next_time = ?, instance_id = ?, acquired_at = ?, timeout = ?, `+
+			`retry_policy = ?, misfire = ?, failed_attempts = ?, last_error = ?, schedule_kind = ?, repeat_interval = ?, recurrence_rule = ?, calendar_name = ?, day_start = ?, day_end = ?, days_of_week = ?, dst_gap = ?, dst_overlap = ?, manual = ? `+
+			`WHERE sched_name = ? AND trigger_key = ?`+cond),
+		args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *sqlStore) UpdateJob(sName string, job jobs.ImmutableJob) error {
+	retryPolicy, err := marshalRetryPolicy(job.RetryPolicy())
+	if err != nil {
+		return err
+	}
+
+	res, err := s.db.Exec(
+		s.query(`UPDATE `+jobsTable+` SET job_type = ?, job_data = ?, job_timeout = ?, job_retry = ?, job_exclusive = ?, `+
+			`job_durable = ?, job_delete_orphaned = ? WHERE sched_name = ? AND job_key = ?`),
+		job.Type(), job.Data(), int64(job.Timeout()), retryPolicy, job.ConcurrentExecutionDisallowed(), job.Durable(),
+		job.DeletedWithLastTrigger(), sName, job.Key(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+func (s *sqlStore) DeleteExhaustedTriggers(sName string) (int, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+triggersTable+` WHERE sched_name = ? AND state = ?`),
+		sName, triggers.StateExhausted,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (s *sqlStore) Heartbeat(sName string, instanceID string, now time.Time) error {
+	_, err := s.db.Exec(
+		s.query(`INSERT INTO `+instancesTable+` (sched_name, instance_id, last_heartbeat) VALUES (?, ?, ?) `+
+			`ON CONFLICT (sched_name, instance_id) DO UPDATE SET last_heartbeat = excluded.last_heartbeat`),
+		sName, instanceID, now.UnixNano(),
+	)
+
+	return err
+}
+
+func (s *sqlStore) GetInstances(sName string) ([]Instance, error) {
+	rows, err := s.db.Query(
+		s.query(`SELECT instance_id, last_heartbeat FROM `+instancesTable+` WHERE sched_name = ?`),
+		sName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arr := make([]Instance, 0)
+	for rows.Next() {
+		var (
+			id        string
+			heartbeat int64
+		)
+		if err := rows.Scan(&id, &heartbeat); err != nil {
+			return nil, err
+		}
+		arr = append(arr, Instance{ID: id, LastHeartbeat: time.Unix(0, heartbeat)})
+	}
+
+	return arr, rows.Err()
+}
+
+func (s *sqlStore) DeleteInstance(sName string, instanceID string) (bool, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+instancesTable+` WHERE sched_name = ? AND instance_id = ?`),
+		sName, instanceID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *sqlStore) LockJob(sName string, jKey string, instanceID string, now time.Time) (bool, error) {
+	res, err := s.db.Exec(
+		s.query(`INSERT INTO `+jobLocksTable+` (sched_name, job_key, instance_id, locked_at) VALUES (?, ?, ?, ?) `+
+			`ON CONFLICT DO NOTHING`),
+		sName, jKey, instanceID, now.UnixNano(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *sqlStore) UnlockJob(sName string, jKey string, instanceID string) error {
+	_, err := s.db.Exec(
+		s.query(`DELETE FROM `+jobLocksTable+` WHERE sched_name = ? AND job_key = ? AND instance_id = ?`),
+		sName, jKey, instanceID,
+	)
+
+	return err
+}
+
+func (s *sqlStore) ReleaseJobLocks(sName string, instanceID string) (int, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+jobLocksTable+` WHERE sched_name = ? AND instance_id = ?`),
+		sName, instanceID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (s *sqlStore) RenewJobLocks(sName string, instanceID string, now time.Time) error {
+	_, err := s.db.Exec(
+		s.query(`UPDATE `+jobLocksTable+` SET locked_at = ? WHERE sched_name = ? AND instance_id = ?`),
+		now.UnixNano(), sName, instanceID,
+	)
+
+	return err
+}
+
+func (s *sqlStore) ReleaseStaleJobLocks(sName string, lockedBefore time.Time) (int, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+jobLocksTable+` WHERE sched_name = ? AND locked_at < ?`),
+		sName, lockedBefore.UnixNano(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+func (s *sqlStore) SaveCalendar(sName string, name string, cal calendars.Calendar) error {
+	b, err := calendars.Marshal(cal)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(
+		s.query(`INSERT INTO `+calendarsTable+` (sched_name, calendar_name, calendar_data) VALUES (?, ?, ?) `+
+			`ON CONFLICT (sched_name, calendar_name) DO UPDATE SET calendar_data = excluded.calendar_data`),
+		sName, name, string(b),
+	)
+
+	return err
+}
+
+func (s *sqlStore) GetCalendar(sName string, name string) (calendars.Calendar, error) {
+	var data string
+	err := s.db.QueryRow(
+		s.query(`SELECT calendar_data FROM `+calendarsTable+` WHERE sched_name = ? AND calendar_name = ?`),
+		sName, name,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return calendars.Unmarshal([]byte(data))
+}
+
+func (s *sqlStore) GetCalendarNames(sName string) ([]string, error) {
+	rows, err := s.db.Query(
+		s.query(`SELECT calendar_name FROM `+calendarsTable+` WHERE sched_name = ? ORDER BY calendar_name`),
+		sName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	arr := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		arr = append(arr, name)
+	}
+
+	return arr, rows.Err()
+}
+
+func (s *sqlStore) DeleteCalendar(sName string, name string) (bool, error) {
+	res, err := s.db.Exec(
+		s.query(`DELETE FROM `+calendarsTable+` WHERE sched_name = ? AND calendar_name = ?`),
+		sName, name,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (s *sqlStore) query(q string) string {
+	return rebind(s.dialect, q)
+}
+
+func NewSQLStore(db *sql.DB, dialect SQLDialect) Store {
+	return &sqlStore{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+func scanJob(row rowScanner) (jobs.ImmutableJob, error) {
+	var (
+		r           JobRecord
+		timeout     int64
+		retryPolicy sql.NullString
+	)
+	if err := row.Scan(&r.Key, &r.Type, &r.Data, &timeout, &retryPolicy, &r.ConcurrentDisallowed, &r.Durable, &r.DeletedWithLastTrigger); err != nil {
+		return nil, err
+	}
+	r.Timeout = time.Duration(timeout)
+
+	policy, err := unmarshalRetryPolicy(retryPolicy)
+	if err != nil {
+		return nil, err
+	}
+	r.RetryPolicy = policy
+
+	return r.Restore(), nil
+}
+
+func scanTrigger(row rowScanner) (triggers.ImmutableTrigger, error) {
+	var (
+		r                          TriggerRecord
+		fromTime, toTime, nextTime sql.NullInt64
+		acquiredAt                 sql.NullInt64
+		repeats, triggeredTimes    int64
+		timeout                    int64
+		retryPolicy                sql.NullString
+		state, misfire, kind       string
+		days, gap, overlap         string
+		interval                   int64
+	)
+
+	err := row.Scan(
+		&r.Key, &r.JobKey, &fromTime, &toTime, &repeats, &r.CronSpec, &r.Location, &r.Data,
+		&state, &triggeredTimes, &nextTime, &r.AcquiredBy, &acquiredAt, &timeout,
+		&retryPolicy, &misfire, &r.FailedAttempts, &r.LastError, &kind, &interval, &r.RRule, &r.CalendarName, &r.DayStart, &r.DayEnd, &days, &gap, &overlap,
+		&r.Manual,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Repeats = triggers.Repeats(repeats)
+	r.TriggeredTimes = triggers.Repeats(triggeredTimes)
+	r.State = triggers.TriggerState(state)
+	r.Timeout = time.Duration(timeout)
+	r.Misfire = triggers.MisfireInstruction(misfire)
+	r.Kind = triggers.ScheduleKind(kind)
+	r.Interval = time.Duration(interval)
+	r.DSTGap = triggers.DSTGapPolicy(gap)
+	r.DSTOverlap = triggers.DSTOverlapPolicy(overlap)
+	if r.DaysOfWeek, err = parseWeekdays(days); err != nil {
+		return nil, err
+	}
+	if r.RetryPolicy, err = unmarshalRetryPolicy(retryPolicy); err != nil {
+		return nil, err
+	}
+
+	r.FromTime = fromNullTime(fromTime, time.Local)
+	r.ToTime = fromNullTime(toTime, time.Local)
+	if next := fromNullTime(nextTime, time.Local); next != nil {
+		r.NextTime = *next
+	}
+	if at := fromNullTime(acquiredAt, time.Local); at != nil {
+		r.AcquiredAt = *at
+	}
+
+	return r.Restore()
+}
+
+func scanTriggers(rows *sql.Rows) ([]triggers.ImmutableTrigger, error) {
+	defer rows.Close()
+
+	arr := make([]triggers.ImmutableTrigger, 0)
+	for rows.Next() {
+		t, err := scanTrigger(rows)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, t)
+	}
+
+	return arr, rows.Err()
+}
+
+func triggerArgs(t triggers.ImmutableTrigger) ([]interface{}, error) {
+	retryPolicy, err := marshalRetryPolicy(t.RetryPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	next := t.NextTriggerTime()
+	acquiredAt := t.AcquiredAt()
+	start, end := t.DailyWindow()
+	gap, overlap := t.DSTPolicy()
+	return []interface{}{
+		t.Key(),
+		t.JobKey(),
+		toNullTime(t.FromTime()),
+		toNullTime(t.ToTime()),
+		int64(t.Repeats()),
+		t.CronSpec(),
+		locationName(t),
+		t.Data(),
+		string(t.State()),
+		int64(t.TriggeredTimes()),
+		toNullTime(&next),
+		t.AcquiredBy(),
+		toNullTime(&acquiredAt),
+		int64(t.Timeout()),
+		retryPolicy,
+		string(t.MisfireInstruction()),
+		t.FailedAttempts(),
+		t.LastError(),
+		string(t.Kind()),
+		int64(t.Interval()),
+		t.RRule(),
+		t.CalendarName(),
+		start,
+		end,
+		formatWeekdays(t.DaysOfWeek()),
+		string(gap),
+		string(overlap),
+		t.Manual(),
+	}, nil
+}
+
+// weekdays as comma separated numbers, Sunday is 0
+func formatWeekdays(days []time.Weekday) string {
+	arr := make([]string, 0, len(days))
+	for _, d := range days {
+		arr = append(arr, strconv.Itoa(int(d)))
+	}
+	return strings.Join(arr, ",")
+}
+
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	if s == "" {
+		return nil, nil
+	}
+	arr := make([]time.Weekday, 0)
+	for _, item := range strings.Split(s, ",") {
+		d, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, time.Weekday(d))
+	}
+	return arr, nil
+}
+
+func marshalRetryPolicy(p *retry.Policy) (sql.NullString, error) {
+	if p == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Provider.Marshal(p)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+func unmarshalRetryPolicy(v sql.NullString) (*retry.Policy, error) {
+	if !v.Valid || v.String == "" {
+		return nil, nil
+	}
+	p := &retry.Policy{}
+	if err := json.Provider.Unmarshal([]byte(v.String), p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func inPlaceholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+func toNullTime(t *time.Time) sql.NullInt64 {
+	if t == nil || t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+func fromNullTime(v sql.NullInt64, loc *time.Location) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := time.Unix(0, v.Int64).In(loc)
+	return &t
+}
@@ -0,0 +1,240 @@
+package stores
+
+import (
+	"database/sql"
+	"github.com/d1slike/go-sched/internal"
+	"github.com/d1slike/go-sched/triggers"
+	_ "github.com/mattn/go-sqlite3"
+	. "github.com/smartystreets/goconvey/convey"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newSQLiteStore(t *testing.T) (Store, func()) {
+	dir, err := ioutil.TempDir("", "go-sched")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "sched.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := CreateSQLSchema(db, SQLiteDialect); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewSQLStore(db, SQLiteDialect), func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func newCronTrigger(key, jobKey string, state triggers.TriggerState) *internal.Trigger {
+	t := internal.NewTrigger()
+	t.Tkey = key
+	t.TjobKey = jobKey
+	t.TcronSpec = "0 0 * * * *"
+	t.Tlocation = "UTC"
+	t.Tstate = state
+	return t
+}
+
+func TestSQLStore_Insert(t *testing.T) {
+	store, cleanup := newSQLiteStore(t)
+	defer cleanup()
+
+	Convey("Test insertion job and triggers", t, func() {
+		Convey("insert job", func() {
+			err := store.InsertJob(sName, &internal.Job{Jkey: "job1", JjType: "type1", Jdata: []byte("data")})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("insert trigger", func() {
+			err := store.InsertTrigger(sName, newCronTrigger("t1", "job1", triggers.StateScheduled))
+			So(err, ShouldBeNil)
+		})
+
+		Convey("must return err if insert job with same key", func() {
+			err := store.InsertJob(sName, &internal.Job{Jkey: "job1", JjType: "type1"})
+			So(err, ShouldEqual, ErrJobAlreadyExists)
+		})
+
+		Convey("must return err if insert trigger with same key", func() {
+			err := store.InsertTrigger(sName, newCronTrigger("t1", "job1", triggers.StateScheduled))
+			So(err, ShouldEqual, ErrTriggerAlreadyExists)
+		})
+
+		Convey("must return job", func() {
+			j, err := store.GetJob(sName, "job1")
+			So(err, ShouldBeNil)
+			So(j, ShouldNotBeNil)
+			So(j.Type(), ShouldEqual, "type1")
+			So(string(j.Data()), ShouldEqual, "data")
+		})
+
+		Convey("must return nil for unknown job", func() {
+			j, err := store.GetJob(sName, "job2")
+			So(err, ShouldBeNil)
+			So(j, ShouldBeNil)
+		})
+	})
+}
+
+func TestSQLStore_TriggerRoundTrip(t *testing.T) {
+	store, cleanup := newSQLiteStore(t)
+	defer cleanup()
+
+	from := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2031, 1, 1, 10, 0, 0, 0, time.UTC)
+	trigger := internal.NewTrigger()
+	trigger.WithKey("t1").
+		WithCron("0 30 * * * *").
+		WithFromTime(from).
+		WithToTime(to).
+		WithRepeats(triggers.Repeat(5)).
+		WithData("payload").
+		InLocation("Europe/Moscow")
+	var nextTime time.Time
+
+	Convey("Test trigger persistence", t, func() {
+		Convey("insert trigger", func() {
+			im, err := trigger.ToImmutable()
+			So(err, ShouldBeNil)
+			im = internal.ModifyTrigger(im, func(tr *internal.Trigger) {
+				tr.TjobKey = "job1"
+				tr.Tstate = triggers.StateScheduled
+				tr.TtriggeredTime = 2
+			})
+			nextTime = im.NextTriggerTime()
+			err = store.InsertTrigger(sName, im)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("must restore all fields", func() {
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr, ShouldNotBeNil)
+			So(tr.JobKey(), ShouldEqual, "job1")
+			So(tr.CronSpec(), ShouldEqual, "0 30 * * * *")
+			So(tr.FromTime().Equal(from), ShouldBeTrue)
+			So(tr.ToTime().Equal(to), ShouldBeTrue)
+			So(tr.Repeats(), ShouldEqual, 5)
+			So(string(tr.Data()), ShouldEqual, "payload")
+			So(tr.Location().String(), ShouldEqual, "Europe/Moscow")
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+			So(tr.TriggeredTimes(), ShouldEqual, 2)
+			So(tr.NextTriggerTime().Equal(nextTime), ShouldBeTrue)
+		})
+
+		Convey("must persist state and next time on update", func() {
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			next := tr.NextTriggerTime().Add(time.Hour)
+			tr = internal.ModifyTrigger(tr, func(t *internal.Trigger) {
+				t.Tstate = triggers.StateExhausted
+				t.TtriggeredTime = 3
+				t.TnextTime = next
+			})
+			So(store.UpdateTrigger(sName, tr), ShouldBeNil)
+
+			tr, err = store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateExhausted)
+			So(tr.TriggeredTimes(), ShouldEqual, 3)
+			So(tr.NextTriggerTime().Equal(next), ShouldBeTrue)
+		})
+	})
+}
+
+func TestSQLStore_UpdateAndDelete(t *testing.T) {
+	store, cleanup := newSQLiteStore(t)
+	defer cleanup()
+
+	Convey("Test updating and deleting", t, func() {
+		Convey("must return err if no entities in store", func() {
+			So(store.UpdateJob(sName, &internal.Job{Jkey: "j1"}), ShouldEqual, ErrJobNotFound)
+			So(store.UpdateTrigger(sName, newCronTrigger("t1", "j1", triggers.StateScheduled)), ShouldEqual, ErrTriggerNotFound)
+		})
+
+		Convey("insert entities", func() {
+			So(store.InsertJob(sName, &internal.Job{Jkey: "j1", JjType: "type1"}), ShouldBeNil)
+			So(store.InsertTrigger(sName, newCronTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+			So(store.InsertTrigger(sName, newCronTrigger("t2", "j1", triggers.StateScheduled)), ShouldBeNil)
+			So(store.InsertTrigger(sName, newCronTrigger("t3", "j3", triggers.StateExhausted)), ShouldBeNil)
+			So(store.InsertTrigger(sName+"1", newCronTrigger("t1", "j1", triggers.StateExhausted)), ShouldBeNil)
+		})
+
+		Convey("update existing job", func() {
+			So(store.UpdateJob(sName, &internal.Job{Jkey: "j1", JjType: "type2"}), ShouldBeNil)
+			j, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(j.Type(), ShouldEqual, "type2")
+		})
+
+		Convey("must delete exhausted triggers of own scheduler", func() {
+			count, err := store.DeleteExhaustedTriggers(sName)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+		})
+
+		Convey("must delete related triggers", func() {
+			arr, err := store.DeleteTriggersByJobKey(sName, "j1")
+			So(err, ShouldBeNil)
+			So(arr, ShouldResemble, []string{"t1", "t2"})
+
+			arr2, err := store.GetTriggers(sName + "1")
+			So(err, ShouldBeNil)
+			So(arr2, ShouldHaveLength, 1)
+		})
+
+		Convey("must delete job", func() {
+			ok, err := store.DeleteJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = store.DeleteJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+func TestSQLStore_AcquireTriggers(t *testing.T) {
+	store, cleanup := newSQLiteStore(t)
+	defer cleanup()
+
+	Convey("Test trigger acquiring", t, func() {
+		Convey("insert triggers", func() {
+			So(store.InsertTrigger(sName, newCronTrigger("t1", "j1", triggers.StateAcquired)), ShouldBeNil)
+			So(store.InsertTrigger(sName, newCronTrigger("t2", "j1", triggers.StateExhausted)), ShouldBeNil)
+			So(store.InsertTrigger(sName, newCronTrigger("t3", "j1", triggers.StateScheduled)), ShouldBeNil)
+			So(store.InsertTrigger(sName, newCronTrigger("t4", "j1", triggers.StateScheduled)), ShouldBeNil)
+			So(store.InsertTrigger(sName+"1", newCronTrigger("t5", "j1", triggers.StateScheduled)), ShouldBeNil)
+		})
+
+		Convey("must return 2 triggers", func() {
+			arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
+			So(err, ShouldBeNil)
+			So(arr, ShouldHaveLength, 2)
+			for _, v := range arr {
+				So(v.State(), ShouldEqual, triggers.StateAcquired)
+			}
+
+			t, err := store.GetTrigger(sName, "t3")
+			So(err, ShouldBeNil)
+			So(t.State(), ShouldEqual, triggers.StateAcquired)
+		})
+
+		Convey("must return empty array", func() {
+			arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
+			So(err, ShouldBeNil)
+			So(arr, ShouldBeEmpty)
+		})
+	})
+}
@@ -2,15 +2,22 @@ package stores
 
 import (
 	"errors"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/executions"
 	"github.com/d1slike/go-sched/jobs"
 	"github.com/d1slike/go-sched/triggers"
+	"time"
 )
 
 var (
-	ErrJobAlreadyExists     = errors.New("job with same key already exists")
-	ErrTriggerAlreadyExists = errors.New("trigger with same key already exists")
-	ErrJobNotFound          = errors.New("job not found")
-	ErrTriggerNotFound      = errors.New("trigger not found")
+	ErrJobAlreadyExists      = errors.New("job with same key already exists")
+	ErrTriggerAlreadyExists  = errors.New("trigger with same key already exists")
+	ErrCalendarAlreadyExists = errors.New("calendar with same name already exists")
+	ErrJobNotFound           = errors.New("job not found")
+	ErrTriggerNotFound       = errors.New("trigger not found")
+	ErrExecutionNotFound     = errors.New("execution not found")
+	ErrCalendarNotFound      = errors.New("calendar not found")
+	ErrTriggerLeaseLost      = errors.New("trigger is not acquired by instance anymore")
 )
 
 type Store interface {
@@ -23,8 +30,75 @@ type Store interface {
 	DeleteTriggersByJobKey(sName string, jKey string) ([]string, error)
 	GetJobs(sName string) ([]jobs.ImmutableJob, error)
 	GetTriggers(sName string) ([]triggers.ImmutableTrigger, error)
-	AcquireTriggers(sName string) ([]triggers.ImmutableTrigger, error)
+	GetTriggersByJobKey(sName string, jKey string) ([]triggers.ImmutableTrigger, error)
+	// acquire at most maxCount (unlimited if <= 0) scheduled triggers with earliest next trigger time
+	// not later than noLaterThan (unbounded if zero)
+	AcquireTriggers(
+		sName string,
+		instanceID string,
+		now time.Time,
+		noLaterThan time.Time,
+		maxCount int,
+	) ([]triggers.ImmutableTrigger, error)
+	RenewTriggers(sName string, instanceID string, tKeys []string, now time.Time) error
+	ReleaseTriggers(sName string, instanceID string) ([]triggers.ImmutableTrigger, error)
+	ReleaseStaleTriggers(sName string, acquiredBefore time.Time) ([]triggers.ImmutableTrigger, error)
 	UpdateTrigger(sName string, trigger triggers.ImmutableTrigger) error
+	// update trigger only if it is still acquired by instance, ErrTriggerLeaseLost otherwise
+	UpdateAcquiredTrigger(sName string, instanceID string, trigger triggers.ImmutableTrigger) error
+	// change only state of trigger if it is in one of from states, acquisition ownership is kept.
+	// return false if trigger does not exist or is in other state
+	UpdateTriggerState(sName string, tKey string, from []triggers.TriggerState, to triggers.TriggerState) (bool, error)
 	UpdateJob(sName string, job jobs.ImmutableJob) error
 	DeleteExhaustedTriggers(sName string) (int, error)
+	Heartbeat(sName string, instanceID string, now time.Time) error
+	GetInstances(sName string) ([]Instance, error)
+	DeleteInstance(sName string, instanceID string) (bool, error)
+	// return false if job is already locked by any instance
+	LockJob(sName string, jKey string, instanceID string, now time.Time) (bool, error)
+	UnlockJob(sName string, jKey string, instanceID string) error
+	// release all job locks held by instance
+	ReleaseJobLocks(sName string, instanceID string) (int, error)
+	// prolong all job locks held by instance, like acquired triggers they are leased
+	RenewJobLocks(sName string, instanceID string, now time.Time) error
+	// release job locks which have not been renewed by their owners since lockedBefore
+	ReleaseStaleJobLocks(sName string, lockedBefore time.Time) (int, error)
+}
+
+// optional store extension persisting calendars referenced by triggers
+type CalendarStore interface {
+	// insert or replace calendar, only calendars supported by calendars.Marshal could be saved
+	SaveCalendar(sName string, name string, cal calendars.Calendar) error
+	// nil if calendar does not exist
+	GetCalendar(sName string, name string) (calendars.Calendar, error)
+	GetCalendarNames(sName string) ([]string, error)
+	DeleteCalendar(sName string, name string) (bool, error)
+}
+
+// optional store extension persisting job execution history
+type ExecutionStore interface {
+	InsertExecution(sName string, execution executions.Execution) error
+	UpdateExecution(sName string, execution executions.Execution) error
+	GetExecution(sName string, id string) (*executions.Execution, error)
+	// newest executions first
+	GetExecutions(sName string, jKey string, filter executions.Filter) ([]executions.Execution, error)
+	// delete finished executions
+	DeleteExecutions(sName string, finishedBefore time.Time) (int, error)
+	// finish running executions of instance with abandoned status
+	AbandonExecutions(sName string, instanceID string, finishedAt time.Time) (int, error)
+	// mark running execution to be interrupted by instance running it, return false if it is not running
+	RequestInterrupt(sName string, id string) (bool, error)
+	// ids of running executions of instance which are requested to be interrupted
+	GetInterruptRequests(sName string, instanceID string) ([]string, error)
+}
+
+// optional store extension making several operations all-or-nothing
+type TransactionalStore interface {
+	// changes made by f through tx are applied only if f returns nil
+	InTransaction(f func(tx Store) error) error
+}
+
+type Instance struct {
+	ID            string
+	LastHeartbeat time.Time
 }
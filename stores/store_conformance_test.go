@@ -0,0 +1,35 @@
+package stores_test
+
+import (
+	"database/sql"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/stores/storetest"
+	_ "github.com/mattn/go-sqlite3"
+	"path/filepath"
+	"testing"
+)
+
+func TestInMemoryStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) stores.Store {
+		return stores.NewInMemoryStore()
+	})
+}
+
+func TestSQLStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) stores.Store {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "sched.db")+"?_busy_timeout=5000")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() {
+			db.Close()
+		})
+
+		if err := stores.CreateSQLSchema(db, stores.SQLiteDialect); err != nil {
+			t.Fatal(err)
+		}
+
+		return stores.NewSQLStore(db, stores.SQLiteDialect)
+	})
+}
@@ -0,0 +1,1266 @@
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/d1slike/go-sched/calendars"
+	"github.com/d1slike/go-sched/executions"
+	"github.com/d1slike/go-sched/jobs"
+	"github.com/d1slike/go-sched/retry"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	sName      = "storetest"
+	otherSName = "storetest_other"
+	instanceID = "instance1"
+	otherID    = "instance2"
+)
+
+// Factory must return a new empty store on every call
+type Factory func(t *testing.T) stores.Store
+
+// Run checks that store implementation satisfies stores.Store contract
+func Run(t *testing.T, factory Factory) {
+	suites := []struct {
+		name string
+		test func(t *testing.T, factory Factory)
+	}{
+		{"Insert", testInsert},
+		{"Get", testGet},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"DeleteTriggersByJobKey", testDeleteTriggersByJobKey},
+		{"DeleteExhaustedTriggers", testDeleteExhaustedTriggers},
+		{"AcquireTriggers", testAcquireTriggers},
+		{"Isolation", testIsolation},
+		{"ConcurrentAcquire", testConcurrentAcquire},
+		{"Ownership", testOwnership},
+		{"BoundedAcquire", testBoundedAcquire},
+		{"Instances", testInstances},
+		{"JobLocks", testJobLocks},
+		{"Calendars", testCalendars},
+		{"Executions", testExecutions},
+		{"Transactions", testTransactions},
+	}
+
+	for _, s := range suites {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			s.test(t, factory)
+		})
+	}
+}
+
+func NewJob(key string) jobs.ImmutableJob {
+	return stores.JobRecord{Key: key, Type: "type"}.Restore()
+}
+
+// hourly cron trigger with next time at start of next hour
+func NewTrigger(key, jobKey string, state triggers.TriggerState) triggers.ImmutableTrigger {
+	return restore(stores.TriggerRecord{
+		Key:      key,
+		JobKey:   jobKey,
+		Kind:     triggers.KindCron,
+		CronSpec: "0 0 * * * *",
+		Repeats:  triggers.RepeatInfinity,
+		Location: "UTC",
+		State:    state,
+		NextTime: time.Now().UTC().Truncate(time.Hour).Add(time.Hour),
+	})
+}
+
+// copy of trigger with changed persisted fields
+func modify(t triggers.ImmutableTrigger, f func(r *stores.TriggerRecord)) triggers.ImmutableTrigger {
+	r := stores.NewTriggerRecord(t)
+	f(&r)
+	return restore(r)
+}
+
+func restore(r stores.TriggerRecord) triggers.ImmutableTrigger {
+	t, err := r.Restore()
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testInsert(t *testing.T, factory Factory) {
+	Convey("Insert", t, func() {
+		store := factory(t)
+
+		So(store.InsertJob(sName, NewJob("j1")), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+
+		Convey("must return err if insert job with same key", func() {
+			So(store.InsertJob(sName, NewJob("j1")), ShouldEqual, stores.ErrJobAlreadyExists)
+		})
+
+		Convey("must return err if insert trigger with same key", func() {
+			err := store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled))
+			So(err, ShouldEqual, stores.ErrTriggerAlreadyExists)
+		})
+	})
+}
+
+func testGet(t *testing.T, factory Factory) {
+	Convey("Get", t, func() {
+		store := factory(t)
+
+		Convey("must return nil if no entities in store", func() {
+			j, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(j, ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr, ShouldBeNil)
+
+			jobs, err := store.GetJobs(sName)
+			So(err, ShouldBeNil)
+			So(jobs, ShouldBeEmpty)
+
+			triggers, err := store.GetTriggers(sName)
+			So(err, ShouldBeNil)
+			So(triggers, ShouldBeEmpty)
+		})
+
+		Convey("must return inserted entities", func() {
+			policy := retry.Exponential(3, time.Second, time.Minute)
+			job := stores.JobRecord{
+				Key:                    "j1",
+				Type:                   "type1",
+				Data:                   []byte("data"),
+				Timeout:                time.Minute,
+				RetryPolicy:            &policy,
+				Durable:                true,
+				DeletedWithLastTrigger: true,
+			}.Restore()
+			So(store.InsertJob(sName, job), ShouldBeNil)
+			So(store.InsertJob(sName, NewJob("j2")), ShouldBeNil)
+			inserted := modify(NewTrigger("t1", "j1", triggers.StateScheduled), func(r *stores.TriggerRecord) {
+				r.Timeout = time.Second
+				r.RetryPolicy = &policy
+				r.FailedAttempts = 2
+				r.LastError = "failed"
+			})
+			So(store.InsertTrigger(sName, inserted), ShouldBeNil)
+			So(store.InsertTrigger(sName, NewTrigger("t2", "j2", triggers.StateScheduled)), ShouldBeNil)
+
+			j, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(j, ShouldNotBeNil)
+			So(j.Key(), ShouldEqual, "j1")
+			So(j.Type(), ShouldEqual, "type1")
+			So(string(j.Data()), ShouldEqual, "data")
+			So(j.Timeout(), ShouldEqual, time.Minute)
+			So(j.RetryPolicy(), ShouldResemble, &policy)
+			So(j.Durable(), ShouldBeTrue)
+			So(j.DeletedWithLastTrigger(), ShouldBeTrue)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr, ShouldNotBeNil)
+			So(tr.Key(), ShouldEqual, "t1")
+			So(tr.JobKey(), ShouldEqual, "j1")
+			So(tr.CronSpec(), ShouldEqual, inserted.CronSpec())
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+			So(tr.NextTriggerTime().Equal(inserted.NextTriggerTime()), ShouldBeTrue)
+			So(tr.Timeout(), ShouldEqual, time.Second)
+			So(tr.RetryPolicy(), ShouldResemble, &policy)
+			So(tr.FailedAttempts(), ShouldEqual, 2)
+			So(tr.LastError(), ShouldEqual, "failed")
+
+			jobs, err := store.GetJobs(sName)
+			So(err, ShouldBeNil)
+			So(jobs, ShouldHaveLength, 2)
+
+			triggers, err := store.GetTriggers(sName)
+			So(err, ShouldBeNil)
+			So(triggers, ShouldHaveLength, 2)
+		})
+
+		Convey("must restore schedule of interval trigger", func() {
+			from := time.Now().Truncate(time.Second).Add(time.Hour)
+			inserted := modify(NewTrigger("t1", "j1", triggers.StateScheduled), func(r *stores.TriggerRecord) {
+				r.Kind = triggers.KindInterval
+				r.Interval = 90 * time.Second
+				r.FromTime = &from
+			})
+			So(store.InsertTrigger(sName, inserted), ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.Kind(), ShouldEqual, triggers.KindInterval)
+			So(tr.Interval(), ShouldEqual, 90*time.Second)
+			So(tr.FromTime().Equal(from), ShouldBeTrue)
+			So(tr.Location().String(), ShouldEqual, "UTC")
+		})
+
+		Convey("must restore schedule of recurrence rule trigger", func() {
+			from := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+			spec := "RRULE:FREQ=MONTHLY;BYDAY=-1FR\nEXDATE:20300125T090000Z"
+			inserted := modify(NewTrigger("t1", "j1", triggers.StateScheduled), func(r *stores.TriggerRecord) {
+				r.Kind = triggers.KindRRule
+				r.RRule = spec
+				r.FromTime = &from
+			})
+			So(store.InsertTrigger(sName, inserted), ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.Kind(), ShouldEqual, triggers.KindRRule)
+			So(tr.RRule(), ShouldEqual, spec)
+			So(tr.FromTime().Equal(from), ShouldBeTrue)
+			So(tr.Location().String(), ShouldEqual, "UTC")
+		})
+
+		Convey("must restore schedule of daily interval trigger", func() {
+			inserted := modify(NewTrigger("t1", "j1", triggers.StateScheduled), func(r *stores.TriggerRecord) {
+				r.Kind = triggers.KindDailyInterval
+				r.DayStart = "09:00"
+				r.DayEnd = "17:00"
+				r.Interval = 30 * time.Minute
+				r.DaysOfWeek = []time.Weekday{time.Monday, time.Friday}
+				r.DSTGap = triggers.DSTGapSkip
+				r.DSTOverlap = triggers.DSTOverlapTwice
+			})
+			So(store.InsertTrigger(sName, inserted), ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.Kind(), ShouldEqual, triggers.KindDailyInterval)
+			start, end := tr.DailyWindow()
+			So(start, ShouldEqual, "09:00")
+			So(end, ShouldEqual, "17:00")
+			So(tr.DaysOfWeek(), ShouldResemble, []time.Weekday{time.Monday, time.Friday})
+			gap, overlap := tr.DSTPolicy()
+			So(gap, ShouldEqual, triggers.DSTGapSkip)
+			So(overlap, ShouldEqual, triggers.DSTOverlapTwice)
+			So(tr.Interval(), ShouldEqual, 30*time.Minute)
+		})
+
+		Convey("must restore schedule of extended cron trigger", func() {
+			inserted := modify(NewTrigger("t1", "j1", triggers.StateScheduled), func(r *stores.TriggerRecord) {
+				r.Kind = triggers.KindExtendedCron
+				r.CronSpec = "0 0 18 LW * ? 2030"
+			})
+			So(store.InsertTrigger(sName, inserted), ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.Kind(), ShouldEqual, triggers.KindExtendedCron)
+			So(tr.CronSpec(), ShouldEqual, "0 0 18 LW * ? 2030")
+			So(tr.NextTriggerTime().Equal(inserted.NextTriggerTime()), ShouldBeTrue)
+		})
+
+		Convey("must return triggers of job", func() {
+			So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+			So(store.InsertTrigger(sName, NewTrigger("t2", "j2", triggers.StateScheduled)), ShouldBeNil)
+			So(store.InsertTrigger(sName, NewTrigger("t3", "j1", triggers.StateExhausted)), ShouldBeNil)
+			So(store.InsertTrigger(otherSName, NewTrigger("t4", "j1", triggers.StateScheduled)), ShouldBeNil)
+
+			arr, err := store.GetTriggersByJobKey(sName, "j1")
+			So(err, ShouldBeNil)
+			So(keysOf(arr), ShouldResemble, []string{"t1", "t3"})
+
+			arr, err = store.GetTriggersByJobKey(sName, "unknown")
+			So(err, ShouldBeNil)
+			So(arr, ShouldBeEmpty)
+		})
+	})
+}
+
+func testUpdate(t *testing.T, factory Factory) {
+	Convey("Update", t, func() {
+		store := factory(t)
+
+		Convey("must return err if no entities in store", func() {
+			So(store.UpdateJob(sName, NewJob("j1")), ShouldEqual, stores.ErrJobNotFound)
+			err := store.UpdateTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled))
+			So(err, ShouldEqual, stores.ErrTriggerNotFound)
+		})
+
+		Convey("must update existing entities", func() {
+			So(store.InsertJob(sName, NewJob("j1")), ShouldBeNil)
+			So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+
+			So(store.UpdateJob(sName, stores.JobRecord{Key: "j1", Type: "type2"}.Restore()), ShouldBeNil)
+			updated := modify(NewTrigger("t1", "j1", triggers.StateExhausted), func(r *stores.TriggerRecord) {
+				r.Repeats = 2
+				r.TriggeredTimes = 2
+				r.Manual = true
+			})
+			So(store.UpdateTrigger(sName, updated), ShouldBeNil)
+
+			j, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(j.Type(), ShouldEqual, "type2")
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.Repeats(), ShouldEqual, 2)
+			So(tr.TriggeredTimes(), ShouldEqual, 2)
+			So(tr.State(), ShouldEqual, triggers.StateExhausted)
+			So(tr.Manual(), ShouldBeTrue)
+		})
+	})
+}
+
+func testDelete(t *testing.T, factory Factory) {
+	Convey("Delete", t, func() {
+		store := factory(t)
+
+		Convey("must return false if nothing was deleted", func() {
+			ok, err := store.DeleteJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = store.DeleteTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("must return true if entity was deleted", func() {
+			So(store.InsertJob(sName, NewJob("j1")), ShouldBeNil)
+			So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+
+			ok, err := store.DeleteJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = store.DeleteTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			j, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(j, ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr, ShouldBeNil)
+		})
+	})
+}
+
+func testDeleteTriggersByJobKey(t *testing.T, factory Factory) {
+	Convey("DeleteTriggersByJobKey", t, func() {
+		store := factory(t)
+
+		So(store.InsertJob(sName, NewJob("j1")), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t2", "j1", triggers.StateAcquired)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t3", "j3", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(otherSName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+
+		keys, err := store.DeleteTriggersByJobKey(sName, "j1")
+		So(err, ShouldBeNil)
+		sort.Strings(keys)
+		So(keys, ShouldResemble, []string{"t1", "t2"})
+
+		remaining, err := store.GetTriggers(sName)
+		So(err, ShouldBeNil)
+		So(remaining, ShouldHaveLength, 1)
+		So(remaining[0].Key(), ShouldEqual, "t3")
+
+		other, err := store.GetTrigger(otherSName, "t1")
+		So(err, ShouldBeNil)
+		So(other, ShouldNotBeNil)
+
+		keys, err = store.DeleteTriggersByJobKey(sName, "j1")
+		So(err, ShouldBeNil)
+		So(keys, ShouldBeEmpty)
+	})
+}
+
+func testDeleteExhaustedTriggers(t *testing.T, factory Factory) {
+	Convey("DeleteExhaustedTriggers", t, func() {
+		store := factory(t)
+
+		So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateAcquired)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t2", "j1", triggers.StateExhausted)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t3", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(otherSName, NewTrigger("t4", "j1", triggers.StateExhausted)), ShouldBeNil)
+
+		count, err := store.DeleteExhaustedTriggers(sName)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+
+		arr, err := store.GetTriggers(sName)
+		So(err, ShouldBeNil)
+		So(arr, ShouldHaveLength, 2)
+
+		arr, err = store.GetTriggers(otherSName)
+		So(err, ShouldBeNil)
+		So(arr, ShouldHaveLength, 1)
+
+		tr, err := store.GetTrigger(sName, "t2")
+		So(err, ShouldBeNil)
+		So(tr, ShouldBeNil)
+	})
+}
+
+func testAcquireTriggers(t *testing.T, factory Factory) {
+	Convey("AcquireTriggers", t, func() {
+		store := factory(t)
+
+		So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateAcquired)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t2", "j1", triggers.StateExhausted)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t3", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t4", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(otherSName, NewTrigger("t5", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t6", "j1", triggers.StatePaused)), ShouldBeNil)
+
+		Convey("must acquire only scheduled triggers", func() {
+			arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
+			So(err, ShouldBeNil)
+			So(keysOf(arr), ShouldResemble, []string{"t3", "t4"})
+			for _, tr := range arr {
+				So(tr.State(), ShouldEqual, triggers.StateAcquired)
+			}
+
+			for _, key := range []string{"t3", "t4"} {
+				tr, err := store.GetTrigger(sName, key)
+				So(err, ShouldBeNil)
+				So(tr.State(), ShouldEqual, triggers.StateAcquired)
+			}
+
+			tr, err := store.GetTrigger(sName, "t2")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateExhausted)
+
+			tr, err = store.GetTrigger(sName, "t6")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StatePaused)
+
+			Convey("must return empty array on second call", func() {
+				arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
+				So(err, ShouldBeNil)
+				So(arr, ShouldBeEmpty)
+			})
+
+			Convey("must acquire released trigger again", func() {
+				tr, err := store.GetTrigger(sName, "t3")
+				So(err, ShouldBeNil)
+				tr = modify(tr, func(r *stores.TriggerRecord) {
+					r.State = triggers.StateScheduled
+				})
+				So(store.UpdateTrigger(sName, tr), ShouldBeNil)
+
+				arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
+				So(err, ShouldBeNil)
+				So(keysOf(arr), ShouldResemble, []string{"t3"})
+			})
+		})
+
+		Convey("must not touch triggers of other scheduler", func() {
+			_, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
+			So(err, ShouldBeNil)
+
+			tr, err := store.GetTrigger(otherSName, "t5")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+		})
+	})
+}
+
+func testIsolation(t *testing.T, factory Factory) {
+	Convey("Isolation between schedulers", t, func() {
+		store := factory(t)
+
+		So(store.InsertJob(sName, NewJob("j1")), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+
+		Convey("entities are invisible to other scheduler", func() {
+			j, err := store.GetJob(otherSName, "j1")
+			So(err, ShouldBeNil)
+			So(j, ShouldBeNil)
+
+			tr, err := store.GetTrigger(otherSName, "t1")
+			So(err, ShouldBeNil)
+			So(tr, ShouldBeNil)
+
+			jobs, err := store.GetJobs(otherSName)
+			So(err, ShouldBeNil)
+			So(jobs, ShouldBeEmpty)
+
+			triggers, err := store.GetTriggers(otherSName)
+			So(err, ShouldBeNil)
+			So(triggers, ShouldBeEmpty)
+		})
+
+		Convey("same keys may be used by other scheduler", func() {
+			So(store.InsertJob(otherSName, NewJob("j1")), ShouldBeNil)
+			So(store.InsertTrigger(otherSName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+		})
+
+		Convey("other scheduler could not update or delete entities", func() {
+			So(store.UpdateJob(otherSName, NewJob("j1")), ShouldEqual, stores.ErrJobNotFound)
+			err := store.UpdateTrigger(otherSName, NewTrigger("t1", "j1", triggers.StateExhausted))
+			So(err, ShouldEqual, stores.ErrTriggerNotFound)
+
+			ok, err := store.DeleteJob(otherSName, "j1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = store.DeleteTrigger(otherSName, "t1")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			j, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(j, ShouldNotBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+		})
+	})
+}
+
+func testConcurrentAcquire(t *testing.T, factory Factory) {
+	const (
+		triggersCount = 50
+		workers       = 8
+	)
+
+	Convey("Concurrent acquiring", t, func() {
+		store := factory(t)
+
+		for i := 0; i < triggersCount; i++ {
+			err := store.InsertTrigger(sName, NewTrigger("t"+strconv.Itoa(i), "j1", triggers.StateScheduled))
+			So(err, ShouldBeNil)
+		}
+
+		var (
+			wg       sync.WaitGroup
+			lock     sync.Mutex
+			acquired = make(map[string]int)
+			errs     = make([]error, 0)
+		)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				arr, err := store.AcquireTriggers(sName, instanceID, time.Now(), time.Time{}, 0)
+				lock.Lock()
+				defer lock.Unlock()
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+				for _, tr := range arr {
+					acquired[tr.Key()]++
+				}
+			}()
+		}
+		wg.Wait()
+
+		So(errs, ShouldBeEmpty)
+		So(acquired, ShouldHaveLength, triggersCount)
+		for _, count := range acquired {
+			So(count, ShouldEqual, 1)
+		}
+	})
+}
+
+func testOwnership(t *testing.T, factory Factory) {
+	Convey("Acquisition ownership", t, func() {
+		store := factory(t)
+
+		acquiredAt := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+		So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t2", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(otherSName, NewTrigger("t3", "j1", triggers.StateScheduled)), ShouldBeNil)
+
+		arr, err := store.AcquireTriggers(sName, instanceID, acquiredAt, time.Time{}, 0)
+		So(err, ShouldBeNil)
+		So(arr, ShouldHaveLength, 2)
+		for _, tr := range arr {
+			So(tr.AcquiredBy(), ShouldEqual, instanceID)
+			So(tr.AcquiredAt().Equal(acquiredAt), ShouldBeTrue)
+		}
+		_, err = store.AcquireTriggers(otherSName, otherID, acquiredAt, time.Time{}, 0)
+		So(err, ShouldBeNil)
+
+		Convey("must persist owner and acquisition time", func() {
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.AcquiredBy(), ShouldEqual, instanceID)
+			So(tr.AcquiredAt().Equal(acquiredAt), ShouldBeTrue)
+		})
+
+		Convey("must renew triggers listed among many keys", func() {
+			now := acquiredAt.Add(time.Minute)
+			keys := make([]string, 0, 2001)
+			for i := 0; i < 2000; i++ {
+				keys = append(keys, "missing"+strconv.Itoa(i))
+			}
+			So(store.RenewTriggers(sName, instanceID, append(keys, "t1"), now), ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.AcquiredAt().Equal(now), ShouldBeTrue)
+		})
+
+		Convey("must renew only listed triggers of owner", func() {
+			now := acquiredAt.Add(time.Minute)
+			So(store.RenewTriggers(sName, instanceID, []string{"t1"}, now), ShouldBeNil)
+			So(store.RenewTriggers(sName, otherID, []string{"t2"}, now), ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.AcquiredAt().Equal(now), ShouldBeTrue)
+
+			tr, err = store.GetTrigger(sName, "t2")
+			So(err, ShouldBeNil)
+			So(tr.AcquiredAt().Equal(acquiredAt), ShouldBeTrue)
+
+			Convey("must release only stale triggers", func() {
+				released, err := store.ReleaseStaleTriggers(sName, now)
+				So(err, ShouldBeNil)
+				So(keysOf(released), ShouldResemble, []string{"t2"})
+				So(released[0].State(), ShouldEqual, triggers.StateScheduled)
+				So(released[0].AcquiredBy(), ShouldBeEmpty)
+
+				tr, err := store.GetTrigger(sName, "t1")
+				So(err, ShouldBeNil)
+				So(tr.State(), ShouldEqual, triggers.StateAcquired)
+
+				tr, err = store.GetTrigger(otherSName, "t3")
+				So(err, ShouldBeNil)
+				So(tr.State(), ShouldEqual, triggers.StateAcquired)
+			})
+		})
+
+		Convey("must update acquired trigger only by its owner", func() {
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			released := modify(tr, func(r *stores.TriggerRecord) {
+				r.State = triggers.StateScheduled
+				r.AcquiredBy = ""
+				r.AcquiredAt = time.Time{}
+				r.TriggeredTimes = 1
+			})
+
+			So(store.UpdateAcquiredTrigger(sName, otherID, released), ShouldEqual, stores.ErrTriggerLeaseLost)
+			tr, err = store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateAcquired)
+			So(tr.TriggeredTimes(), ShouldEqual, 0)
+
+			So(store.UpdateAcquiredTrigger(sName, instanceID, released), ShouldBeNil)
+			tr, err = store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+			So(tr.TriggeredTimes(), ShouldEqual, 1)
+
+			//released trigger is not owned by anyone anymore
+			So(store.UpdateAcquiredTrigger(sName, instanceID, released), ShouldEqual, stores.ErrTriggerLeaseLost)
+			So(store.UpdateAcquiredTrigger(sName, instanceID, NewTrigger("unknown", "j1", triggers.StateScheduled)), ShouldEqual, stores.ErrTriggerNotFound)
+		})
+
+		Convey("must change only state of trigger in expected state", func() {
+			paused := []triggers.TriggerState{triggers.StatePaused}
+			acquired := []triggers.TriggerState{triggers.StateScheduled, triggers.StateAcquired}
+
+			ok, err := store.UpdateTriggerState(sName, "t1", paused, triggers.StateScheduled)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+			ok, err = store.UpdateTriggerState(sName, "unknown", acquired, triggers.StatePaused)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = store.UpdateTriggerState(sName, "t1", acquired, triggers.StatePaused)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StatePaused)
+			So(tr.AcquiredBy(), ShouldEqual, instanceID)
+			So(tr.AcquiredAt().Equal(acquiredAt), ShouldBeTrue)
+
+			//paused trigger is neither renewed nor released as stale
+			released, err := store.ReleaseStaleTriggers(sName, acquiredAt.Add(time.Hour))
+			So(err, ShouldBeNil)
+			So(keysOf(released), ShouldResemble, []string{"t2"})
+
+			ok, err = store.UpdateTriggerState(sName, "t1", paused, triggers.StateScheduled)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			tr, err = store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+		})
+
+		Convey("must release triggers of instance", func() {
+			released, err := store.ReleaseTriggers(sName, otherID)
+			So(err, ShouldBeNil)
+			So(released, ShouldBeEmpty)
+
+			released, err = store.ReleaseTriggers(sName, instanceID)
+			So(err, ShouldBeNil)
+			So(keysOf(released), ShouldResemble, []string{"t1", "t2"})
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+			So(tr.AcquiredBy(), ShouldBeEmpty)
+			So(tr.AcquiredAt().IsZero(), ShouldBeTrue)
+
+			arr, err := store.AcquireTriggers(sName, otherID, acquiredAt, time.Time{}, 0)
+			So(err, ShouldBeNil)
+			So(arr, ShouldHaveLength, 2)
+		})
+	})
+}
+
+func testBoundedAcquire(t *testing.T, factory Factory) {
+	Convey("Bounded acquiring", t, func() {
+		store := factory(t)
+
+		now := time.Now().Truncate(time.Second)
+		for i, offset := range []time.Duration{3, 1, 4, 2, 60} {
+			tr := modify(
+				NewTrigger("t"+strconv.Itoa(i), "j1", triggers.StateScheduled),
+				func(r *stores.TriggerRecord) {
+					r.NextTime = now.Add(offset * time.Second)
+				},
+			)
+			So(store.InsertTrigger(sName, tr), ShouldBeNil)
+		}
+
+		Convey("must acquire triggers with earliest next time first", func() {
+			arr, err := store.AcquireTriggers(sName, instanceID, now, time.Time{}, 2)
+			So(err, ShouldBeNil)
+			So(keysOf(arr), ShouldResemble, []string{"t1", "t3"})
+
+			arr, err = store.AcquireTriggers(sName, otherID, now, time.Time{}, 2)
+			So(err, ShouldBeNil)
+			So(keysOf(arr), ShouldResemble, []string{"t0", "t2"})
+		})
+
+		Convey("must not acquire triggers beyond window", func() {
+			arr, err := store.AcquireTriggers(sName, instanceID, now, now.Add(10*time.Second), 0)
+			So(err, ShouldBeNil)
+			So(keysOf(arr), ShouldResemble, []string{"t0", "t1", "t2", "t3"})
+
+			tr, err := store.GetTrigger(sName, "t4")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+		})
+	})
+}
+
+func testInstances(t *testing.T, factory Factory) {
+	Convey("Instance heartbeats", t, func() {
+		store := factory(t)
+
+		now := time.Now().Truncate(time.Millisecond)
+		So(store.Heartbeat(sName, instanceID, now.Add(-time.Minute)), ShouldBeNil)
+		So(store.Heartbeat(sName, otherID, now), ShouldBeNil)
+		So(store.Heartbeat(otherSName, instanceID, now), ShouldBeNil)
+
+		Convey("must return instances of scheduler", func() {
+			arr, err := store.GetInstances(sName)
+			So(err, ShouldBeNil)
+			So(arr, ShouldHaveLength, 2)
+		})
+
+		Convey("must update last heartbeat", func() {
+			So(store.Heartbeat(sName, instanceID, now), ShouldBeNil)
+
+			arr, err := store.GetInstances(sName)
+			So(err, ShouldBeNil)
+			So(arr, ShouldHaveLength, 2)
+			for _, inst := range arr {
+				So(inst.LastHeartbeat.Equal(now), ShouldBeTrue)
+			}
+		})
+
+		Convey("must delete instance", func() {
+			ok, err := store.DeleteInstance(sName, instanceID)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = store.DeleteInstance(sName, instanceID)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			arr, err := store.GetInstances(sName)
+			So(err, ShouldBeNil)
+			So(arr, ShouldHaveLength, 1)
+			So(arr[0].ID, ShouldEqual, otherID)
+
+			arr, err = store.GetInstances(otherSName)
+			So(err, ShouldBeNil)
+			So(arr, ShouldHaveLength, 1)
+		})
+	})
+}
+
+func testJobLocks(t *testing.T, factory Factory) {
+	Convey("Job locks", t, func() {
+		store := factory(t)
+		now := time.Now()
+
+		Convey("must persist concurrent execution flag", func() {
+			So(store.InsertJob(sName, stores.JobRecord{Key: "j1", Type: "type", ConcurrentDisallowed: true}.Restore()), ShouldBeNil)
+
+			job, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(job.ConcurrentExecutionDisallowed(), ShouldBeTrue)
+		})
+
+		Convey("job must be locked only once", func() {
+			ok, err := store.LockJob(sName, "j1", instanceID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = store.LockJob(sName, "j1", instanceID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = store.LockJob(sName, "j1", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = store.LockJob(otherSName, "j1", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("only owner could unlock job", func() {
+			ok, err := store.LockJob(sName, "j1", instanceID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			So(store.UnlockJob(sName, "j1", otherID), ShouldBeNil)
+			ok, err = store.LockJob(sName, "j1", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			So(store.UnlockJob(sName, "j1", instanceID), ShouldBeNil)
+			ok, err = store.LockJob(sName, "j1", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+		})
+
+		Convey("must release all locks of instance", func() {
+			for _, key := range []string{"j1", "j2"} {
+				ok, err := store.LockJob(sName, key, instanceID, now)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+			}
+			ok, err := store.LockJob(sName, "j3", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			count, err := store.ReleaseJobLocks(sName, instanceID)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+
+			ok, err = store.LockJob(sName, "j1", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			ok, err = store.LockJob(sName, "j3", instanceID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("must release only locks which have not been renewed", func() {
+			for _, key := range []string{"j1", "j2"} {
+				ok, err := store.LockJob(sName, key, instanceID, now)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeTrue)
+			}
+			ok, err := store.LockJob(sName, "j3", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			ok, err = store.LockJob(otherSName, "j1", otherID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			So(store.RenewJobLocks(sName, instanceID, now.Add(time.Minute)), ShouldBeNil)
+			count, err := store.ReleaseStaleJobLocks(sName, now.Add(time.Second))
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+
+			ok, err = store.LockJob(sName, "j3", instanceID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+			for _, key := range []string{"j1", "j2"} {
+				ok, err = store.LockJob(sName, key, otherID, now)
+				So(err, ShouldBeNil)
+				So(ok, ShouldBeFalse)
+			}
+			ok, err = store.LockJob(otherSName, "j1", instanceID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
+
+// runs only if store implements optional stores.CalendarStore
+func testCalendars(t *testing.T, factory Factory) {
+	if _, ok := factory(t).(stores.CalendarStore); !ok {
+		t.Skip("store does not support calendars")
+	}
+
+	Convey("Calendars", t, func() {
+		store := factory(t)
+		cals := store.(stores.CalendarStore)
+
+		holidays := calendars.NewHolidayCalendar(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+		holidays.SetLocation(time.UTC)
+		holidays.SetBase(calendars.NewWeeklyCalendar(time.Saturday, time.Sunday))
+		So(cals.SaveCalendar(sName, "holidays", holidays), ShouldBeNil)
+		So(cals.SaveCalendar(sName, "weekends", calendars.NewWeeklyCalendar(time.Saturday)), ShouldBeNil)
+		So(cals.SaveCalendar(otherSName, "other", calendars.NewWeeklyCalendar(time.Monday)), ShouldBeNil)
+
+		Convey("must return saved calendars", func() {
+			cal, err := cals.GetCalendar(sName, "holidays")
+			So(err, ShouldBeNil)
+			So(cal, ShouldNotBeNil)
+			So(cal.Type(), ShouldEqual, calendars.TypeHoliday)
+			So(cal.Location(), ShouldEqual, time.UTC)
+			So(cal.IsIncluded(time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)), ShouldBeFalse)
+			So(cal.IsIncluded(time.Date(2030, 1, 5, 12, 0, 0, 0, time.UTC)), ShouldBeFalse)
+			So(cal.IsIncluded(time.Date(2030, 1, 7, 12, 0, 0, 0, time.UTC)), ShouldBeTrue)
+
+			cal, err = cals.GetCalendar(sName, "unknown")
+			So(err, ShouldBeNil)
+			So(cal, ShouldBeNil)
+
+			names, err := cals.GetCalendarNames(sName)
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{"holidays", "weekends"})
+		})
+
+		Convey("must replace calendar with same name", func() {
+			So(cals.SaveCalendar(sName, "weekends", calendars.NewWeeklyCalendar(time.Sunday)), ShouldBeNil)
+
+			cal, err := cals.GetCalendar(sName, "weekends")
+			So(err, ShouldBeNil)
+			So(cal.(*calendars.WeeklyCalendar).ExcludedDays(), ShouldResemble, []time.Weekday{time.Sunday})
+		})
+
+		Convey("must delete calendar", func() {
+			ok, err := cals.DeleteCalendar(sName, "weekends")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = cals.DeleteCalendar(sName, "weekends")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			names, err := cals.GetCalendarNames(otherSName)
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{"other"})
+		})
+
+		Convey("must persist calendar name of trigger", func() {
+			inserted := modify(NewTrigger("t1", "j1", triggers.StateScheduled), func(r *stores.TriggerRecord) {
+				r.CalendarName = "holidays"
+			})
+			So(store.InsertTrigger(sName, inserted), ShouldBeNil)
+
+			tr, err := store.GetTrigger(sName, "t1")
+			So(err, ShouldBeNil)
+			So(tr.CalendarName(), ShouldEqual, "holidays")
+		})
+	})
+}
+
+// runs only if store implements optional stores.ExecutionStore
+func testExecutions(t *testing.T, factory Factory) {
+	if _, ok := factory(t).(stores.ExecutionStore); !ok {
+		t.Skip("store does not support execution history")
+	}
+
+	Convey("Execution history", t, func() {
+		store := factory(t).(stores.ExecutionStore)
+		start := time.Now().Truncate(time.Millisecond)
+		for i, status := range []executions.Status{
+			executions.StatusSucceeded,
+			executions.StatusFailed,
+			executions.StatusSucceeded,
+			executions.StatusRunning,
+		} {
+			execution := executions.Execution{
+				ID:         fmt.Sprintf("e%d", i),
+				JobKey:     "j1",
+				TriggerKey: "t1",
+				InstanceID: instanceID,
+				Attempt:    1,
+				StartedAt:  start.Add(time.Duration(i) * time.Minute),
+				Status:     executions.StatusRunning,
+			}
+			So(store.InsertExecution(sName, execution), ShouldBeNil)
+			if status != executions.StatusRunning {
+				execution.Status = status
+				execution.FinishedAt = execution.StartedAt.Add(time.Second)
+				execution.Result = []byte("result")
+				So(store.UpdateExecution(sName, execution), ShouldBeNil)
+			}
+		}
+		So(store.InsertExecution(sName, executions.Execution{ID: "e4", JobKey: "j2", StartedAt: start, Status: executions.StatusRunning}), ShouldBeNil)
+		So(store.InsertExecution(otherSName, executions.Execution{ID: "e0", JobKey: "j1", StartedAt: start}), ShouldBeNil)
+
+		Convey("must return execution by id", func() {
+			execution, err := store.GetExecution(sName, "e1")
+			So(err, ShouldBeNil)
+			So(execution, ShouldNotBeNil)
+			So(execution.Status, ShouldEqual, executions.StatusFailed)
+			So(execution.TriggerKey, ShouldEqual, "t1")
+			So(execution.InstanceID, ShouldEqual, instanceID)
+			So(execution.Attempt, ShouldEqual, 1)
+			So(execution.StartedAt.Equal(start.Add(time.Minute)), ShouldBeTrue)
+			So(execution.Duration(), ShouldEqual, time.Second)
+			So(string(execution.Result), ShouldEqual, "result")
+
+			execution, err = store.GetExecution(sName, "unknown")
+			So(err, ShouldBeNil)
+			So(execution, ShouldBeNil)
+		})
+
+		Convey("must return executions of job newest first", func() {
+			arr, err := store.GetExecutions(sName, "j1", executions.Filter{})
+			So(err, ShouldBeNil)
+			So(executionIDs(arr), ShouldResemble, []string{"e3", "e2", "e1", "e0"})
+
+			arr, err = store.GetExecutions(sName, "j1", executions.Filter{Status: executions.StatusSucceeded, Limit: 1})
+			So(err, ShouldBeNil)
+			So(executionIDs(arr), ShouldResemble, []string{"e2"})
+
+			arr, err = store.GetExecutions(sName, "j1", executions.Filter{
+				StartedFrom: start.Add(time.Minute),
+				StartedTo:   start.Add(3 * time.Minute),
+			})
+			So(err, ShouldBeNil)
+			So(executionIDs(arr), ShouldResemble, []string{"e2", "e1"})
+		})
+
+		Convey("must return err if update unknown execution", func() {
+			So(store.UpdateExecution(sName, executions.Execution{ID: "unknown"}), ShouldEqual, stores.ErrExecutionNotFound)
+		})
+
+		Convey("must request interrupt of running executions only", func() {
+			ok, err := store.RequestInterrupt(sName, "e3")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			ok, err = store.RequestInterrupt(sName, "e2")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ok, err = store.RequestInterrupt(sName, "unknown")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			ids, err := store.GetInterruptRequests(sName, instanceID)
+			So(err, ShouldBeNil)
+			So(ids, ShouldResemble, []string{"e3"})
+
+			ids, err = store.GetInterruptRequests(sName, "other")
+			So(err, ShouldBeNil)
+			So(ids, ShouldBeEmpty)
+
+			execution, err := store.GetExecution(sName, "e3")
+			So(err, ShouldBeNil)
+			So(execution.InterruptRequested, ShouldBeTrue)
+
+			execution.Status = executions.StatusInterrupted
+			execution.FinishedAt = execution.StartedAt.Add(time.Second)
+			So(store.UpdateExecution(sName, *execution), ShouldBeNil)
+			ids, err = store.GetInterruptRequests(sName, instanceID)
+			So(err, ShouldBeNil)
+			So(ids, ShouldBeEmpty)
+		})
+
+		Convey("must abandon running executions of instance only", func() {
+			finishedAt := start.Add(time.Hour)
+			count, err := store.AbandonExecutions(sName, instanceID, finishedAt)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+
+			execution, err := store.GetExecution(sName, "e3")
+			So(err, ShouldBeNil)
+			So(execution.Status, ShouldEqual, executions.StatusAbandoned)
+			So(execution.FinishedAt.Equal(finishedAt), ShouldBeTrue)
+
+			execution, err = store.GetExecution(sName, "e2")
+			So(err, ShouldBeNil)
+			So(execution.Status, ShouldEqual, executions.StatusSucceeded)
+			execution, err = store.GetExecution(sName, "e4")
+			So(err, ShouldBeNil)
+			So(execution.Status, ShouldEqual, executions.StatusRunning)
+
+			ok, err := store.RequestInterrupt(sName, "e3")
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+
+			count, err = store.AbandonExecutions(sName, instanceID, finishedAt)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 0)
+		})
+
+		Convey("must delete only finished executions", func() {
+			count, err := store.DeleteExecutions(sName, start.Add(2*time.Minute))
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+
+			arr, err := store.GetExecutions(sName, "j1", executions.Filter{})
+			So(err, ShouldBeNil)
+			So(executionIDs(arr), ShouldResemble, []string{"e3", "e2"})
+
+			arr, err = store.GetExecutions(otherSName, "j1", executions.Filter{})
+			So(err, ShouldBeNil)
+			So(arr, ShouldHaveLength, 1)
+		})
+	})
+}
+
+// runs only if store implements optional stores.TransactionalStore
+func testTransactions(t *testing.T, factory Factory) {
+	if _, ok := factory(t).(stores.TransactionalStore); !ok {
+		t.Skip("store does not support transactions")
+	}
+
+	Convey("Transactions", t, func() {
+		store := factory(t)
+		txStore := store.(stores.TransactionalStore)
+		So(store.InsertJob(sName, NewJob("j1")), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t1", "j1", triggers.StateScheduled)), ShouldBeNil)
+		So(store.InsertTrigger(sName, NewTrigger("t2", "j1", triggers.StateScheduled)), ShouldBeNil)
+		txErr := errors.New("tx failed")
+
+		Convey("must apply all changes on success", func() {
+			So(txStore.InTransaction(func(tx stores.Store) error {
+				if err := tx.InsertJob(sName, NewJob("j2")); err != nil {
+					return err
+				}
+				return tx.InsertTrigger(sName, NewTrigger("t3", "j2", triggers.StateScheduled))
+			}), ShouldBeNil)
+
+			j, err := store.GetJob(sName, "j2")
+			So(err, ShouldBeNil)
+			So(j, ShouldNotBeNil)
+			tr, err := store.GetTrigger(sName, "t3")
+			So(err, ShouldBeNil)
+			So(tr, ShouldNotBeNil)
+		})
+
+		Convey("must not leave job if trigger insert has failed", func() {
+			So(txStore.InTransaction(func(tx stores.Store) error {
+				if err := tx.InsertJob(sName, NewJob("j2")); err != nil {
+					return err
+				}
+				return tx.InsertTrigger(sName, NewTrigger("t1", "j2", triggers.StateScheduled))
+			}), ShouldEqual, stores.ErrTriggerAlreadyExists)
+
+			j, err := store.GetJob(sName, "j2")
+			So(err, ShouldBeNil)
+			So(j, ShouldBeNil)
+		})
+
+		Convey("must restore deleted and updated entities on failure", func() {
+			So(txStore.InTransaction(func(tx stores.Store) error {
+				updated := NewTrigger("t2", "j1", triggers.StatePaused)
+				if err := tx.UpdateTrigger(sName, updated); err != nil {
+					return err
+				}
+				if _, err := tx.DeleteTriggersByJobKey(sName, "j1"); err != nil {
+					return err
+				}
+				if _, err := tx.DeleteJob(sName, "j1"); err != nil {
+					return err
+				}
+				return txErr
+			}), ShouldEqual, txErr)
+
+			j, err := store.GetJob(sName, "j1")
+			So(err, ShouldBeNil)
+			So(j, ShouldNotBeNil)
+			arr, err := store.GetTriggersByJobKey(sName, "j1")
+			So(err, ShouldBeNil)
+			So(keysOf(arr), ShouldResemble, []string{"t1", "t2"})
+			tr, err := store.GetTrigger(sName, "t2")
+			So(err, ShouldBeNil)
+			So(tr.State(), ShouldEqual, triggers.StateScheduled)
+		})
+
+		Convey("must restore job locks on failure", func() {
+			now := time.Now()
+			ok, err := store.LockJob(sName, "j1", instanceID, now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+
+			So(txStore.InTransaction(func(tx stores.Store) error {
+				if err := tx.UnlockJob(sName, "j1", instanceID); err != nil {
+					return err
+				}
+				if _, err := tx.LockJob(sName, "j2", instanceID, now); err != nil {
+					return err
+				}
+				return txErr
+			}), ShouldEqual, txErr)
+
+			ok, err = store.LockJob(sName, "j1", "other", now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeFalse)
+			ok, err = store.LockJob(sName, "j2", "other", now)
+			So(err, ShouldBeNil)
+			So(ok, ShouldBeTrue)
+		})
+
+		cals, ok := store.(stores.CalendarStore)
+		if !ok {
+			return
+		}
+
+		Convey("must restore calendars on failure", func() {
+			So(cals.SaveCalendar(sName, "holidays", calendars.NewHolidayCalendar()), ShouldBeNil)
+
+			So(txStore.InTransaction(func(tx stores.Store) error {
+				txCals := tx.(stores.CalendarStore)
+				if _, err := txCals.DeleteCalendar(sName, "holidays"); err != nil {
+					return err
+				}
+				if err := txCals.SaveCalendar(sName, "weekends", calendars.NewWeeklyCalendar()); err != nil {
+					return err
+				}
+				return txErr
+			}), ShouldEqual, txErr)
+
+			names, err := cals.GetCalendarNames(sName)
+			So(err, ShouldBeNil)
+			So(names, ShouldResemble, []string{"holidays"})
+		})
+	})
+}
+
+func executionIDs(arr []executions.Execution) []string {
+	ids := make([]string, 0, len(arr))
+	for _, e := range arr {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func keysOf(arr []triggers.ImmutableTrigger) []string {
+	keys := make([]string, 0, len(arr))
+	for _, t := range arr {
+		keys = append(keys, t.Key())
+	}
+	sort.Strings(keys)
+	return keys
+}
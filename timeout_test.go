@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/clock"
+	"github.com/d1slike/go-sched/executions"
+	"github.com/d1slike/go-sched/retry"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"testing"
+	"time"
+)
+
+func TestScheduler_JobTimeout(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second, JobTimeout: time.Minute}
+	fireTime := start.Truncate(time.Minute).Add(time.Minute)
+
+	cases := []struct {
+		name       string
+		jobTimeout time.Duration
+		triTimeout time.Duration
+		expected   time.Duration
+	}{
+		{name: "trigger timeout must override job and default ones", jobTimeout: 20 * time.Second, triTimeout: 5 * time.Second, expected: 5 * time.Second},
+		{name: "job timeout must override default one", jobTimeout: 20 * time.Second, expected: 20 * time.Second},
+		{name: "default timeout must be used if neither trigger nor job has own one", expected: time.Minute},
+	}
+	for _, c := range cases {
+		Convey("Job context must be cancelled on timeout, "+c.name, t, func() {
+			fake := clock.NewFake(start)
+			deadlines := make(chan time.Time, 1)
+			finished := make(chan error, 1)
+			s := NewScheduler("timeout", WithClock(fake), WithTimers(timers))
+			s.RegisterExecutor("type", func(ctx JobContext) error {
+				deadline, _ := ctx.Context().Deadline()
+				deadlines <- deadline
+				<-ctx.Context().Done()
+				finished <- ctx.Context().Err()
+				return nil
+			})
+			So(s.ScheduleJob(
+				NewJob().WithKey("j1").WithType("type").WithTimeout(c.jobTimeout),
+				NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC").WithTimeout(c.triTimeout),
+			), ShouldBeNil)
+
+			s.Start()
+			defer s.Shutdown(context.Background())
+
+			fireAt(s, fake, timers, "t1", fireTime)
+			So((<-deadlines).Equal(fireTime.Add(c.expected)), ShouldBeTrue)
+
+			fake.Advance(c.expected - time.Millisecond)
+			select {
+			case err := <-finished:
+				So(err, ShouldBeNil)
+			case <-time.After(10 * time.Millisecond):
+			}
+
+			fake.Advance(time.Millisecond)
+			So(<-finished == context.DeadlineExceeded, ShouldBeTrue)
+		})
+	}
+
+	Convey("Worker must be held until timed out job returns", t, func() {
+		fake := clock.NewFake(start)
+		release := make(chan struct{})
+		s := NewScheduler("timeout", WithClock(fake), WithTimers(timers), WithWorkerPool(1, SaturationBlock))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			//cancellation is ignored
+			<-release
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type").WithTimeout(5*time.Second),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+		fireAt(s, fake, timers, "t1", fireTime)
+
+		//execution is finished as timed out
+		fake.BlockUntil(3)
+		fake.Advance(5 * time.Second)
+		So(waitFor(func() bool {
+			arr, err := s.GetExecutions("j1", executions.Filter{Status: executions.StatusFailed})
+			return err == nil && len(arr) == 1
+		}), ShouldBeTrue)
+		So(s.WorkerPoolStats().Busy, ShouldEqual, 1)
+
+		close(release)
+		So(waitFor(func() bool {
+			return s.WorkerPoolStats().Busy == 0
+		}), ShouldBeTrue)
+	})
+
+	Convey("Shutdown must wait for running job", t, func() {
+		fake := clock.NewFake(start)
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		finished := make(chan error, 1)
+		s := NewScheduler("timeout", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			started <- struct{}{}
+			<-release
+			finished <- ctx.Context().Err()
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		fireAt(s, fake, timers, "t1", fireTime)
+		<-started
+
+		shutdown := make(chan error, 1)
+		go func() {
+			shutdown <- s.Shutdown(context.Background())
+		}()
+		select {
+		case <-shutdown:
+			So("shutdown has not waited for job", ShouldBeEmpty)
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		close(release)
+		So(<-finished, ShouldBeNil)
+		So(<-shutdown, ShouldBeNil)
+
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.TriggeredTimes(), ShouldEqual, 1)
+	})
+
+	Convey("Job context must be cancelled when shutdown context is done", t, func() {
+		fake := clock.NewFake(start)
+		started := make(chan struct{}, 1)
+		finished := make(chan error, 1)
+		s := NewScheduler("timeout", WithClock(fake), WithTimers(timers))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			started <- struct{}{}
+			<-ctx.Context().Done()
+			finished <- ctx.Context().Err()
+			return ctx.Context().Err()
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type").WithRetryPolicy(retry.Fixed(3, time.Second)),
+			NewTrigger().WithKey("t1").WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+
+		s.Start()
+		fireAt(s, fake, timers, "t1", fireTime)
+		<-started
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		So(s.Shutdown(ctx), ShouldBeNil)
+		So(<-finished == context.Canceled, ShouldBeTrue)
+
+		//canceled firing is neither counted nor retried, it is fired again later
+		So(waitFor(func() bool {
+			tr, err := s.GetTrigger("t1")
+			return err == nil && tr.State() == triggers.StateScheduled
+		}), ShouldBeTrue)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.TriggeredTimes(), ShouldEqual, 0)
+		So(tr.FailedAttempts(), ShouldEqual, 0)
+		So(tr.NextTriggerTime().Equal(fireTime), ShouldBeTrue)
+	})
+}
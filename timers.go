@@ -3,11 +3,36 @@ package scheduler
 import "time"
 
 const (
-	DefaultTriggerStealTimeout = 1 * time.Second
+	DefaultTriggerStealTimeout     = 1 * time.Second
+	DefaultTriggerRecoveryInterval = 15 * time.Second
+	DefaultTriggerRecoveryTimeout  = 1 * time.Minute
+	DefaultClusterCheckinInterval  = 5 * time.Second
+	DefaultClusterInstanceTimeout  = 20 * time.Second
+	DefaultClusterAcquireWindow    = 30 * time.Second
+	DefaultClusterAcquireBatchSize = 10
+	DefaultMisfireThreshold        = 10 * time.Second
 )
 
 type Timers struct {
 	TriggerStealTimeout time.Duration
+	// how often acquired triggers are renewed and stale ones are reclaimed
+	TriggerRecoveryInterval time.Duration
+	// acquired trigger is stale if its owner has not renewed it for this duration
+	TriggerRecoveryTimeout time.Duration
+	// how often clustered instance reports its heartbeat
+	ClusterCheckinInterval time.Duration
+	// clustered instance is dead if it has not reported heartbeat for this duration
+	ClusterInstanceTimeout time.Duration
+	// clustered instance acquires only triggers which fire within this window
+	ClusterAcquireWindow time.Duration
+	// max triggers acquired by clustered instance at once
+	ClusterAcquireBatchSize int
+	// trigger is misfired if it has not been fired within this duration after its fire time
+	MisfireThreshold time.Duration
+	// default job execution timeout, used if neither trigger nor job has own timeout. zero means no timeout
+	JobTimeout time.Duration
+	// finished executions older than this duration are deleted from history. zero means keep forever
+	ExecutionRetention time.Duration
 }
 
 func NewDefaultTimers() Timers {
@@ -18,6 +43,33 @@ func SetDefault(t Timers) Timers {
 	if t.TriggerStealTimeout <= 0 {
 		t.TriggerStealTimeout = DefaultTriggerStealTimeout
 	}
+	if t.TriggerRecoveryInterval <= 0 {
+		t.TriggerRecoveryInterval = DefaultTriggerRecoveryInterval
+	}
+	if t.TriggerRecoveryTimeout <= 0 {
+		t.TriggerRecoveryTimeout = DefaultTriggerRecoveryTimeout
+	}
+	if t.TriggerRecoveryTimeout <= t.TriggerRecoveryInterval {
+		t.TriggerRecoveryTimeout = 2 * t.TriggerRecoveryInterval
+	}
+	if t.ClusterCheckinInterval <= 0 {
+		t.ClusterCheckinInterval = DefaultClusterCheckinInterval
+	}
+	if t.ClusterInstanceTimeout <= 0 {
+		t.ClusterInstanceTimeout = DefaultClusterInstanceTimeout
+	}
+	if t.ClusterInstanceTimeout <= t.ClusterCheckinInterval {
+		t.ClusterInstanceTimeout = 2 * t.ClusterCheckinInterval
+	}
+	if t.ClusterAcquireWindow <= 0 {
+		t.ClusterAcquireWindow = DefaultClusterAcquireWindow
+	}
+	if t.ClusterAcquireBatchSize <= 0 {
+		t.ClusterAcquireBatchSize = DefaultClusterAcquireBatchSize
+	}
+	if t.MisfireThreshold <= 0 {
+		t.MisfireThreshold = DefaultMisfireThreshold
+	}
 
 	return t
 }
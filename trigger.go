@@ -3,8 +3,44 @@ package scheduler
 import (
 	"github.com/d1slike/go-sched/internal"
 	"github.com/d1slike/go-sched/triggers"
+	"time"
+)
+
+const (
+	// key prefix of one-shot triggers created by Scheduler.TriggerJob, they are recognized by IsManualTrigger
+	ManualTriggerKeyPrefix = "manual-"
 )
 
 func NewTrigger() triggers.MutableTrigger {
 	return internal.NewTrigger()
 }
+
+func IsManualTrigger(t triggers.ImmutableTrigger) bool {
+	return t.Manual()
+}
+
+// one-shot trigger firing job at given time, deleted once exhausted
+func newManualTrigger(jKey string, data interface{}, fireTime time.Time) (triggers.ImmutableTrigger, error) {
+	t := internal.NewTrigger()
+	if data != nil {
+		b, err := internal.CastData(data)
+		if err != nil {
+			return nil, err
+		}
+		t.Tdata = b
+	}
+	t.At(fireTime)
+	if err := t.Restore(); err != nil {
+		return nil, err
+	}
+
+	t.Tkey = ManualTriggerKeyPrefix + newExecutionID()
+	t.TjobKey = jKey
+	t.Trepeats = triggers.RepeatOnce
+	t.Tmisfire = triggers.MisfireFireNow
+	t.Tstate = triggers.StateScheduled
+	t.TnextTime = fireTime.In(t.Tloc)
+	t.Tmanual = true
+
+	return t, nil
+}
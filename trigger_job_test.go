@@ -0,0 +1,122 @@
+package scheduler
+
+import (
+	"context"
+	"github.com/d1slike/go-sched/executions"
+	"github.com/d1slike/go-sched/stores"
+	"github.com/d1slike/go-sched/triggers"
+	. "github.com/smartystreets/goconvey/convey"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduler_TriggerJob(t *testing.T) {
+	Convey("Job must be fired once on demand without changing its triggers", t, func() {
+		fired := make(chan string, 1)
+		s := NewScheduler("trigger_job", WithTimers(Timers{TriggerStealTimeout: 50 * time.Millisecond}))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			var data struct {
+				Date string `json:"date"`
+			}
+			if err := ctx.UnmarshalTriggerData(&data); err != nil {
+				return err
+			}
+			fired <- data.Date
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("t1").WithCron("0 0 0 * * *"),
+		), ShouldBeNil)
+
+		So(s.TriggerJob("unknown", nil), ShouldEqual, stores.ErrJobNotFound)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		So(s.TriggerJob("j1", map[string]string{"date": "2020-01-01"}), ShouldBeNil)
+		var date string
+		select {
+		case date = <-fired:
+		case <-time.After(3 * time.Second):
+		}
+		So(date, ShouldEqual, "2020-01-01")
+
+		So(waitFor(func() bool {
+			arr, err := s.GetTriggers()
+			return err == nil && len(arr) == 1
+		}), ShouldBeTrue)
+		tr, err := s.GetTrigger("t1")
+		So(err, ShouldBeNil)
+		So(tr.TriggeredTimes(), ShouldEqual, 0)
+		So(tr.State(), ShouldNotEqual, triggers.StateExhausted)
+
+		So(waitFor(func() bool {
+			arr, err := s.GetExecutions("j1", executions.Filter{Status: executions.StatusSucceeded})
+			return err == nil && len(arr) == 1 && strings.HasPrefix(arr[0].TriggerKey, ManualTriggerKeyPrefix)
+		}), ShouldBeTrue)
+	})
+}
+
+func TestScheduler_ManualTriggers(t *testing.T) {
+	Convey("Manual triggers must be recognized by flag and hidden from listing", t, func() {
+		store := stores.NewInMemoryStore()
+		s := NewScheduler(
+			"trigger_job",
+			WithStore(store),
+			WithTimers(Timers{TriggerStealTimeout: 50 * time.Millisecond}),
+		)
+		fired := make(chan string, 2)
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().Key()
+			return nil
+		})
+		So(s.ScheduleJob(
+			NewJob().WithKey("j1").WithType("type"),
+			NewTrigger().WithKey("manual-user").After(100*time.Millisecond),
+		), ShouldBeNil)
+
+		So(s.TriggerJob("j1", nil), ShouldBeNil)
+		all, err := store.GetTriggers("trigger_job")
+		So(err, ShouldBeNil)
+		So(all, ShouldHaveLength, 2)
+
+		arr, err := s.GetTriggers()
+		So(err, ShouldBeNil)
+		So(triggerKeys(arr), ShouldResemble, []string{"manual-user"})
+		arr, err = s.GetTriggersOfJob("j1")
+		So(err, ShouldBeNil)
+		So(triggerKeys(arr), ShouldResemble, []string{"manual-user"})
+
+		So(s.PauseAll(), ShouldBeNil)
+		for _, tr := range all {
+			if tr.Manual() {
+				manual, err := s.GetTrigger(tr.Key())
+				So(err, ShouldBeNil)
+				So(manual.State(), ShouldEqual, triggers.StateScheduled)
+			}
+		}
+		So(s.ResumeAll(), ShouldBeNil)
+
+		s.Start()
+		defer s.Shutdown(context.Background())
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-fired:
+			case <-time.After(3 * time.Second):
+			}
+		}
+
+		//exhausted trigger of user is kept even if its key looks like key of manual one
+		So(waitFor(func() bool {
+			tr, err := s.GetTrigger("manual-user")
+			return err == nil && tr != nil && tr.State() == triggers.StateExhausted
+		}), ShouldBeTrue)
+		So(waitFor(func() bool {
+			arr, err := store.GetTriggers("trigger_job")
+			return err == nil && len(arr) == 1
+		}), ShouldBeTrue)
+	})
+}
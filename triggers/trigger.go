@@ -2,6 +2,7 @@ package triggers
 
 import (
 	"errors"
+	"github.com/d1slike/go-sched/retry"
 	"time"
 )
 
@@ -14,28 +15,108 @@ const (
 	StateScheduled = TriggerState("SCHEDULED")
 	StateAcquired  = TriggerState("ACQUIRED")
 	StateExhausted = TriggerState("EXHAUSTED")
+	StatePaused    = TriggerState("PAUSED")
+)
+
+const (
+	// fire once as soon as possible, next fire time is calculated from actual fire time
+	MisfireFireNow = MisfireInstruction("FIRE_NOW")
+	// fire every missed fire time one by one
+	MisfireFireAll = MisfireInstruction("FIRE_ALL")
+	// do not fire, reschedule to next fire time, missed fire times are not counted as triggered
+	MisfireSkip = MisfireInstruction("SKIP")
+	// do not fire, reschedule to next fire time, missed fire times are counted as triggered
+	MisfireDoNothing = MisfireInstruction("DO_NOTHING")
+)
+
+const (
+	// fire at wall clock time shifted forward by length of spring-forward gap, e.g. 02:30 is fired at 03:30
+	DSTGapShift = DSTGapPolicy("SHIFT")
+	// do not fire at wall clock time within spring-forward gap
+	DSTGapSkip = DSTGapPolicy("SKIP")
+	// fire at first occurrence of wall clock time repeated by fall-back overlap
+	DSTOverlapOnce = DSTOverlapPolicy("ONCE")
+	// fire at both occurrences of wall clock time repeated by fall-back overlap
+	DSTOverlapTwice = DSTOverlapPolicy("TWICE")
+)
+
+const (
+	// fires by cron spec
+	KindCron = ScheduleKind("CRON")
+	// fires by extended cron spec with seconds, years and Quartz modifiers
+	KindExtendedCron = ScheduleKind("EXTENDED_CRON")
+	// fires every interval starting at from time
+	KindInterval = ScheduleKind("INTERVAL")
+	// fires once at from time
+	KindOnce = ScheduleKind("ONCE")
+	// fires by iCalendar recurrence rule starting at from time
+	KindRRule = ScheduleKind("RRULE")
+	// fires every interval within time window of day on chosen weekdays
+	KindDailyInterval = ScheduleKind("DAILY_INTERVAL")
 )
 
 var (
-	ErrEmptyTriggerKey  = errors.New("empty trigger key")
-	ErrEmptyCronSpec    = errors.New("empty cron specification")
-	ErrAlreadyExhausted = errors.New("trigger already exhausted")
-	ErrInvalidLocation  = "invalid location: %v"
-	ErrInvalidCronSpec  = "invalid cron spec: %v"
+	ErrEmptyTriggerKey     = errors.New("empty trigger key")
+	ErrEmptyCronSpec       = errors.New("empty cron specification")
+	ErrAlreadyExhausted    = errors.New("trigger already exhausted")
+	ErrInvalidInterval     = errors.New("interval must be positive")
+	ErrEmptyFireTime       = errors.New("empty fire time of one-shot trigger")
+	ErrEmptyRRule          = errors.New("empty recurrence rule")
+	ErrInvalidTimeWindow   = errors.New("end time of day must not be before start time of day")
+	ErrInvalidLocation     = "invalid location: %v"
+	ErrInvalidCronSpec     = "invalid cron spec: %v"
+	ErrInvalidRRule        = "invalid recurrence rule: %v"
+	ErrInvalidTimeOfDay    = "invalid time of day: %v"
+	ErrInvalidDSTPolicy    = "invalid DST policy: %v"
+	ErrInvalidMisfire      = "invalid misfire instruction: %v"
+	ErrInvalidScheduleKind = "invalid schedule kind: %v"
 )
 
 type Repeats int
 
 type TriggerState string
 
+type MisfireInstruction string
+
+type ScheduleKind string
+
+type DSTGapPolicy string
+
+type DSTOverlapPolicy string
+
 type MutableTrigger interface {
 	WithKey(tKey string) MutableTrigger
 	WithFromTime(from time.Time) MutableTrigger
 	WithToTime(to time.Time) MutableTrigger
 	WithRepeats(repeats Repeats) MutableTrigger
 	WithCron(spec string) MutableTrigger
+	// fire by extended cron spec with optional seconds and year fields, "L", "W", "#" and "?" like Quartz,
+	// e.g. "0 0 18 L-2 * ?" or "0 15 10 ? * 6#3 2030", see cronexpr.Parse
+	WithExtendedCron(spec string) MutableTrigger
+	// fire every interval starting at from time, or one interval after scheduling time if from time is not set
+	WithInterval(interval time.Duration) MutableTrigger
+	// fire by RFC 5545 recurrence rule, e.g. "FREQ=MONTHLY;BYDAY=-1FR", optionally with RDATE and EXDATE lines.
+	// from time is DTSTART of rule, scheduling time truncated to seconds is used if from time is not set
+	WithRRule(spec string) MutableTrigger
+	// fire every interval from start to end time of day inclusive, given as "15:04" or "15:04:05",
+	// on given weekdays or every day if none are given. times of day are taken in trigger location
+	WithDailyInterval(start, end string, interval time.Duration, days ...time.Weekday) MutableTrigger
+	// how wall clock times of cron and daily interval triggers are fired around DST transitions of trigger location,
+	// DSTGapShift and DSTOverlapOnce are used if not set. interval and one-shot triggers are not affected by DST,
+	// recurrence rule triggers follow RFC 5545 which shifts times within gap and fires first of repeated ones
+	WithDSTPolicy(gap DSTGapPolicy, overlap DSTOverlapPolicy) MutableTrigger
+	// fire once at given time
+	At(fireTime time.Time) MutableTrigger
+	// fire once after given delay from scheduling time
+	After(delay time.Duration) MutableTrigger
+	// exclude times excluded by calendar with given name from fire times
+	WithCalendar(name string) MutableTrigger
 	WithData(value interface{}) MutableTrigger
 	InLocation(loc string) MutableTrigger
+	WithTimeout(timeout time.Duration) MutableTrigger
+	WithRetryPolicy(policy retry.Policy) MutableTrigger
+	// scheduler default instruction is used if not set
+	WithMisfireInstruction(instruction MisfireInstruction) MutableTrigger
 	ToImmutable() (ImmutableTrigger, error)
 }
 
@@ -46,13 +127,59 @@ type ImmutableTrigger interface {
 	FromTime() *time.Time
 	ToTime() *time.Time
 	Repeats() Repeats
+	Kind() ScheduleKind
 	CronSpec() string
+	Interval() time.Duration
+	RRule() string
+	// start and end time of day of daily interval trigger
+	DailyWindow() (string, string)
+	// weekdays of daily interval trigger, empty means every day
+	DaysOfWeek() []time.Weekday
+	CalendarName() string
+	DSTPolicy() (DSTGapPolicy, DSTOverlapPolicy)
 	Location() *time.Location
+	Timeout() time.Duration
+	RetryPolicy() *retry.Policy
+	MisfireInstruction() MisfireInstruction
+	// failed attempts of current firing
+	FailedAttempts() int
+	LastError() string
 	State() TriggerState
 	TriggeredTimes() Repeats
 	NextTriggerTime() time.Time
+	AcquiredBy() string
+	AcquiredAt() time.Time
+	// one-shot trigger created by Scheduler.TriggerJob, it is deleted once fired
+	Manual() bool
 }
 
 func Repeat(count int) Repeats {
 	return Repeats(count)
 }
+
+func (i MisfireInstruction) IsValid() bool {
+	switch i {
+	case "", MisfireFireNow, MisfireFireAll, MisfireSkip, MisfireDoNothing:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p DSTGapPolicy) IsValid() bool {
+	switch p {
+	case "", DSTGapShift, DSTGapSkip:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p DSTOverlapPolicy) IsValid() bool {
+	switch p {
+	case "", DSTOverlapOnce, DSTOverlapTwice:
+		return true
+	default:
+		return false
+	}
+}
@@ -21,6 +21,18 @@ func (b *AtomicBool) Set(value bool) {
 	}
 }
 
+// set new value if current one equals to old, return true if value was set
+func (b *AtomicBool) CompareAndSwap(old, new bool) bool {
+	return atomic.CompareAndSwapInt32(&b.v, boolToInt(old), boolToInt(new))
+}
+
+func boolToInt(value bool) int32 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
 func NewAtomicBool(init bool) *AtomicBool {
 	b := &AtomicBool{}
 	if init {
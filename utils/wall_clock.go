@@ -0,0 +1,39 @@
+package utils
+
+import "time"
+
+// wall clock time given by fields of t as UTC time, so it is not affected by DST transitions
+func Floating(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// zone offsets in effect a day before and a day after given time, they differ around DST transition
+func NearOffsets(t time.Time, loc *time.Location) (time.Duration, time.Duration) {
+	_, before := t.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := t.Add(24 * time.Hour).In(loc).Zone()
+	return time.Duration(before) * time.Second, time.Duration(after) * time.Second
+}
+
+// resolve floating wall clock time in location. return its instants ascending: two within fall-back overlap,
+// one otherwise. time within spring-forward gap does not exist, it is shifted forward by length of gap then
+func ResolveWallClock(wall time.Time, loc *time.Location) (instants []time.Time, gap bool) {
+	before, after := NearOffsets(wall, loc)
+	arr := make([]time.Time, 0, 2)
+	for _, offset := range []time.Duration{before, after} {
+		t := wall.Add(-offset).In(loc)
+		if Floating(t).Equal(wall) && (len(arr) == 0 || !arr[0].Equal(t)) {
+			arr = append(arr, t)
+		}
+	}
+	if len(arr) == 0 {
+		return []time.Time{wall.Add(-before).In(loc)}, true
+	}
+	return arr, false
+}
+
+func MaxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// wait for free worker, waiting firings are served in order of arrival
+	SaturationBlock = SaturationPolicy("BLOCK")
+	// skip firing and reschedule trigger to its next fire time
+	SaturationDrop = SaturationPolicy("DROP")
+	// return trigger to store to be fired later, misfire instruction is applied if it becomes too late
+	SaturationDelay = SaturationPolicy("DELAY")
+)
+
+type SaturationPolicy string
+
+type PoolStats struct {
+	// max concurrently executed jobs, zero means unlimited
+	Size int
+	// currently executed jobs
+	Busy int
+	// firings waiting for free worker
+	Queued  int
+	Dropped int64
+	Delayed int64
+}
+
+type workerPool struct {
+	lock  sync.Mutex
+	size  int
+	busy  int
+	queue []chan struct{}
+
+	dropped int64
+	delayed int64
+}
+
+func (p *workerPool) TryAcquire() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.size > 0 && (p.busy >= p.size || len(p.queue) > 0) {
+		return false
+	}
+	p.busy++
+	return true
+}
+
+// wait for free worker in FIFO order, return false if ctx is done before
+func (p *workerPool) Acquire(ctx context.Context) bool {
+	p.lock.Lock()
+	if p.size <= 0 || (p.busy < p.size && len(p.queue) == 0) {
+		p.busy++
+		p.lock.Unlock()
+		return true
+	}
+	ready := make(chan struct{})
+	p.queue = append(p.queue, ready)
+	p.lock.Unlock()
+
+	select {
+	case <-ready:
+		return true
+	case <-ctx.Done():
+		p.lock.Lock()
+		defer p.lock.Unlock()
+		for i, ch := range p.queue {
+			if ch == ready {
+				p.queue = append(p.queue[:i], p.queue[i+1:]...)
+				return false
+			}
+		}
+		//worker has been handed over concurrently
+		p.releaseLocked()
+		return false
+	}
+}
+
+func (p *workerPool) Release() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.releaseLocked()
+}
+
+// hand worker over to first waiting firing
+func (p *workerPool) releaseLocked() {
+	if len(p.queue) > 0 {
+		ready := p.queue[0]
+		p.queue = p.queue[1:]
+		close(ready)
+		return
+	}
+	p.busy--
+}
+
+func (p *workerPool) Dropped() {
+	p.lock.Lock()
+	p.dropped++
+	p.lock.Unlock()
+}
+
+func (p *workerPool) Delayed() {
+	p.lock.Lock()
+	p.delayed++
+	p.lock.Unlock()
+}
+
+func (p *workerPool) Stats() PoolStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return PoolStats{
+		Size:    p.size,
+		Busy:    p.busy,
+		Queued:  len(p.queue),
+		Dropped: p.dropped,
+		Delayed: p.delayed,
+	}
+}
+
+func newWorkerPool(size int) *workerPool {
+	return &workerPool{
+		size: size,
+	}
+}
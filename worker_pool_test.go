@@ -0,0 +1,198 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/d1slike/go-sched/clock"
+	. "github.com/smartystreets/goconvey/convey"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	Convey("Waiting firings must be served in order of arrival", t, func() {
+		pool := newWorkerPool(1)
+		So(pool.Acquire(context.Background()), ShouldBeTrue)
+		So(pool.TryAcquire(), ShouldBeFalse)
+
+		order := make(chan int, 3)
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if pool.Acquire(context.Background()) {
+					order <- i
+					pool.Release()
+				}
+			}(i)
+			//wait until firing is queued
+			for pool.Stats().Queued != i+1 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		So(pool.Stats(), ShouldResemble, PoolStats{Size: 1, Busy: 1, Queued: 3})
+
+		pool.Release()
+		wg.Wait()
+		close(order)
+		arr := make([]int, 0)
+		for i := range order {
+			arr = append(arr, i)
+		}
+		So(arr, ShouldResemble, []int{0, 1, 2})
+		So(pool.Stats(), ShouldResemble, PoolStats{Size: 1})
+	})
+
+	Convey("Waiting must be aborted when context is done", t, func() {
+		pool := newWorkerPool(1)
+		So(pool.TryAcquire(), ShouldBeTrue)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			//wait until firing is queued
+			for pool.Stats().Queued != 1 {
+				time.Sleep(time.Millisecond)
+			}
+			cancel()
+		}()
+		So(pool.Acquire(ctx), ShouldBeFalse)
+		So(pool.Stats().Queued, ShouldEqual, 0)
+	})
+}
+
+// schedule jobs firing at same time and advance fake clock to it
+func fireTogether(s Scheduler, fake *clock.Fake, timers Timers, count int, fireTime time.Time) {
+	for i := 0; i < count; i++ {
+		So(s.ScheduleJob(
+			NewJob().WithKey(fmt.Sprintf("j%d", i)).WithType("type"),
+			NewTrigger().WithKey(fmt.Sprintf("t%d", i)).WithCron("0 * * * * *").InLocation("UTC"),
+		), ShouldBeNil)
+	}
+
+	s.Start()
+	fake.BlockUntil(2)
+	fake.Advance(timers.TriggerStealTimeout)
+	for i := 0; i < count; i++ {
+		So(waitFor(triggerAcquired(s, fmt.Sprintf("t%d", i))), ShouldBeTrue)
+	}
+	//steal loop, recovery ticker and trigger futures are waiting
+	fake.BlockUntil(2 + count)
+	fake.Advance(fireTime.Sub(fake.Now()))
+}
+
+func TestScheduler_WorkerPool(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 30, 0, time.UTC)
+	timers := Timers{TriggerStealTimeout: time.Second}
+	fireTime := start.Truncate(time.Minute).Add(time.Minute)
+
+	Convey("Concurrency must be bounded by worker pool", t, func() {
+		var running, maxRunning int32
+		fake := clock.NewFake(start)
+		release := make(chan struct{})
+		s := NewScheduler("pool", WithClock(fake), WithTimers(timers), WithWorkerPool(2, SaturationBlock))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			cur := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if cur <= max || atomic.CompareAndSwapInt32(&maxRunning, max, cur) {
+					break
+				}
+			}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+		fireTogether(s, fake, timers, 4, fireTime)
+
+		So(waitFor(func() bool {
+			return s.WorkerPoolStats() == PoolStats{Size: 2, Busy: 2, Queued: 2}
+		}), ShouldBeTrue)
+		close(release)
+		for i := 0; i < 4; i++ {
+			So(waitFor(triggeredTimes(s, fmt.Sprintf("t%d", i), 1)), ShouldBeTrue)
+		}
+		So(s.Shutdown(context.Background()), ShouldBeNil)
+
+		So(atomic.LoadInt32(&maxRunning), ShouldEqual, 2)
+		So(s.WorkerPoolStats(), ShouldResemble, PoolStats{Size: 2})
+	})
+
+	Convey("Firings must be dropped when pool is saturated", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan string, 3)
+		release := make(chan struct{})
+		s := NewScheduler("pool", WithClock(fake), WithTimers(timers), WithWorkerPool(1, SaturationDrop))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().Key()
+			<-release
+			return nil
+		})
+		fireTogether(s, fake, timers, 3, fireTime)
+
+		executed := <-fired
+		So(waitFor(func() bool {
+			return s.WorkerPoolStats().Dropped == 2
+		}), ShouldBeTrue)
+		close(release)
+		So(waitFor(triggeredTimes(s, executed, 1)), ShouldBeTrue)
+		So(s.Shutdown(context.Background()), ShouldBeNil)
+		So(fired, ShouldBeEmpty)
+
+		//dropped firings are rescheduled to next fire time
+		arr, err := s.GetTriggers()
+		So(err, ShouldBeNil)
+		for _, tr := range arr {
+			So(tr.NextTriggerTime().Equal(fireTime.Add(time.Minute)), ShouldBeTrue)
+			if tr.Key() != executed {
+				So(tr.TriggeredTimes(), ShouldEqual, 0)
+			}
+		}
+	})
+
+	Convey("Firings must be delayed when pool is saturated", t, func() {
+		fake := clock.NewFake(start)
+		fired := make(chan string, 2)
+		release := make(chan struct{})
+		s := NewScheduler("pool", WithClock(fake), WithTimers(timers), WithWorkerPool(1, SaturationDelay))
+		s.RegisterExecutor("type", func(ctx JobContext) error {
+			fired <- ctx.Trigger().Key()
+			<-release
+			return nil
+		})
+		fireTogether(s, fake, timers, 2, fireTime)
+		defer s.Shutdown(context.Background())
+
+		executed := <-fired
+		delayed := "t0"
+		if executed == delayed {
+			delayed = "t1"
+		}
+		So(waitFor(func() bool {
+			return s.WorkerPoolStats().Delayed == 1
+		}), ShouldBeTrue)
+
+		//delayed firing is returned to store with same fire time
+		tr, err := s.GetTrigger(delayed)
+		So(err, ShouldBeNil)
+		So(tr.TriggeredTimes(), ShouldEqual, 0)
+		So(tr.NextTriggerTime().Equal(fireTime), ShouldBeTrue)
+
+		close(release)
+		So(waitFor(triggeredTimes(s, executed, 1)), ShouldBeTrue)
+
+		//and fired late after it is stolen again
+		var late string
+		for i := 0; late == "" && i < 100; i++ {
+			fake.Advance(timers.TriggerStealTimeout)
+			select {
+			case late = <-fired:
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		So(late, ShouldEqual, delayed)
+		So(waitFor(triggeredTimes(s, delayed, 1)), ShouldBeTrue)
+	})
+}